@@ -0,0 +1,165 @@
+package accesslist
+
+import (
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+// KeyKind 区分读写集中key的种类，storage slot之外都是伪key
+type KeyKind int
+
+const (
+	KindBalance KeyKind = iota
+	KindNonce
+	KindCode
+	KindCodeHash
+	KindAlive
+	KindStorage
+	KindNum
+)
+
+func (kind KeyKind) String() string {
+	switch kind {
+	case KindBalance:
+		return "balance"
+	case KindNonce:
+		return "nonce"
+	case KindCode:
+		return "code"
+	case KindCodeHash:
+		return "codeHash"
+	case KindAlive:
+		return "alive"
+	case KindStorage:
+		return "storage"
+	default:
+		return "unknown"
+	}
+}
+
+func GetKeyKind(hash common.Hash) KeyKind {
+	switch hash {
+	case BALANCE:
+		return KindBalance
+	case NONCE:
+		return KindNonce
+	case CODE:
+		return KindCode
+	case CODEHASH:
+		return KindCodeHash
+	case ALIVE:
+		return KindAlive
+	default:
+		return KindStorage
+	}
+}
+
+// Confusion 记录预测集合相对真实集合的命中情况
+type Confusion struct {
+	TP uint // 预测到且真实访问
+	FP uint // 预测到但没有访问
+	FN uint // 真实访问但没有预测到
+}
+
+func (c *Confusion) Merge(other Confusion) {
+	c.TP += other.TP
+	c.FP += other.FP
+	c.FN += other.FN
+}
+
+// 分母为0时说明没有可以预测错的key，约定为1
+func (c Confusion) Precision() float64 {
+	if c.TP+c.FP == 0 {
+		return 1
+	}
+	return float64(c.TP) / float64(c.TP+c.FP)
+}
+
+func (c Confusion) Recall() float64 {
+	if c.TP+c.FN == 0 {
+		return 1
+	}
+	return float64(c.TP) / float64(c.TP+c.FN)
+}
+
+func (c Confusion) IsEmpty() bool {
+	return c.TP+c.FP+c.FN == 0
+}
+
+// Accuracy 按key种类分别统计读集和写集
+type Accuracy struct {
+	Read  [KindNum]Confusion
+	Write [KindNum]Confusion
+}
+
+func (a *Accuracy) Merge(other Accuracy) {
+	for kind := KeyKind(0); kind < KindNum; kind++ {
+		a.Read[kind].Merge(other.Read[kind])
+		a.Write[kind].Merge(other.Write[kind])
+	}
+}
+
+// 所有种类合在一起的结果
+func (a Accuracy) ReadTotal() Confusion {
+	var total Confusion
+	for _, c := range a.Read {
+		total.Merge(c)
+	}
+	return total
+}
+
+func (a Accuracy) WriteTotal() Confusion {
+	var total Confusion
+	for _, c := range a.Write {
+		total.Merge(c)
+	}
+	return total
+}
+
+func compareALTuple(predict, truth ALTuple, res *[KindNum]Confusion) {
+	for addr, state := range predict {
+		for hash := range state {
+			if truth.Contains(addr, hash) {
+				res[GetKeyKind(hash)].TP++
+			} else {
+				res[GetKeyKind(hash)].FP++
+			}
+		}
+	}
+	for addr, state := range truth {
+		for hash := range state {
+			if !predict.Contains(addr, hash) {
+				res[GetKeyKind(hash)].FN++
+			}
+		}
+	}
+}
+
+func (RWSets RWSet) allWrites() ALTuple {
+	writes := make(ALTuple)
+	for addr, state := range RWSets.WriteSet {
+		for hash := range state {
+			writes.Add(addr, hash)
+		}
+	}
+	for addr, state := range RWSets.DeltaSet {
+		for hash := range state {
+			writes.Add(addr, hash)
+		}
+	}
+	return writes
+}
+
+// CompareRWSet 以truth为准统计predict的准确率，nil视为空集
+func CompareRWSet(predict, truth *RWSet) Accuracy {
+	var res Accuracy
+	if predict == nil {
+		predict = NewRWSet()
+	}
+	if truth == nil {
+		truth = NewRWSet()
+	}
+	compareALTuple(predict.ReadSet, truth.ReadSet, &res.Read)
+	// 加法写也是写，统计时和普通写合在一起
+	compareALTuple(predict.allWrites(), truth.allWrites(), &res.Write)
+	return res
+}
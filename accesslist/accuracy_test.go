@@ -0,0 +1,42 @@
+package accesslist
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+func TestCompareRWSet(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	slot1 := common.HexToHash("0x01")
+	slot2 := common.HexToHash("0x02")
+
+	predict := NewRWSet()
+	predict.AddReadSet(addr, BALANCE)
+	predict.AddReadSet(addr, slot1)
+	predict.AddWriteSet(addr, slot1)
+
+	truth := NewRWSet()
+	truth.AddReadSet(addr, BALANCE)
+	truth.AddReadSet(addr, slot2)
+	truth.AddWriteSet(addr, NONCE)
+
+	res := CompareRWSet(predict, truth)
+	if res.Read[KindBalance] != (Confusion{TP: 1}) {
+		t.Fatalf("unexpected balance read: %+v", res.Read[KindBalance])
+	}
+	if res.Read[KindStorage] != (Confusion{FP: 1, FN: 1}) {
+		t.Fatalf("unexpected storage read: %+v", res.Read[KindStorage])
+	}
+	if res.Write[KindNonce] != (Confusion{FN: 1}) || res.Write[KindStorage] != (Confusion{FP: 1}) {
+		t.Fatalf("unexpected write: %+v", res.Write)
+	}
+	if p, r := res.ReadTotal().Precision(), res.ReadTotal().Recall(); p != 0.5 || r != 0.5 {
+		t.Fatalf("unexpected read precision/recall: %v %v", p, r)
+	}
+
+	missing := CompareRWSet(nil, truth)
+	if missing.ReadTotal().FN != 2 || missing.ReadTotal().Precision() != 1 || missing.ReadTotal().Recall() != 0 {
+		t.Fatalf("unexpected result for nil prediction: %+v", missing.ReadTotal())
+	}
+}
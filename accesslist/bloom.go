@@ -0,0 +1,62 @@
+package accesslist
+
+import (
+	"encoding/binary"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+const bloomWords = 32 // 2048位
+
+// KeyBloom 每个key只置1位，两个集合有公共key时对应位一定同时为1
+type KeyBloom [bloomWords]uint64
+
+func bloomBit(addr common.Address, hash common.Hash) uint {
+	// storage slot可能是很小的整数，所以取末尾8字节再混合
+	h := binary.BigEndian.Uint64(addr[12:]) ^ binary.BigEndian.Uint64(hash[24:])*0x9E3779B97F4A7C15
+	h ^= h >> 29
+	h *= 0xBF58476D1CE4E5B9
+	return uint(h >> (64 - 11))
+}
+
+func (b *KeyBloom) Add(addr common.Address, hash common.Hash) {
+	bit := bloomBit(addr, hash)
+	b[bit/64] |= 1 << (bit % 64)
+}
+
+func (b *KeyBloom) addTuple(tuple ALTuple) {
+	for addr, state := range tuple {
+		for hash := range state {
+			b.Add(addr, hash)
+		}
+	}
+}
+
+// rwBloom 读、写、加法写分别建过滤器，才能排除加法与加法之间的假冲突
+type rwBloom struct {
+	read  KeyBloom
+	write KeyBloom
+	delta KeyBloom
+}
+
+// 返回false时两个集合一定不冲突，返回true时还需要精确判断
+func (b *rwBloom) mayConflict(other *rwBloom) bool {
+	for i := 0; i < bloomWords; i++ {
+		if b.read[i]&(other.write[i]|other.delta[i]) != 0 ||
+			b.write[i]&(other.read[i]|other.write[i]|other.delta[i]) != 0 ||
+			b.delta[i]&(other.read[i]|other.write[i]) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildBloom 为读写集建立布隆过滤器，之后HasConflict会先用它排除不冲突的交易对；
+// 之后再加入的key会同步加入过滤器，删除的key不会清除，只会多出假阳性
+func (RWSets *RWSet) BuildBloom() {
+	b := new(rwBloom)
+	b.read.addTuple(RWSets.ReadSet)
+	b.write.addTuple(RWSets.WriteSet)
+	b.delta.addTuple(RWSets.DeltaSet)
+	RWSets.bloom = b
+}
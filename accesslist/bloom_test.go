@@ -0,0 +1,55 @@
+package accesslist
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+func randomRWSet(r *rand.Rand) *RWSet {
+	set := NewRWSet()
+	n := 1 + r.Intn(8)
+	for i := 0; i < n; i++ {
+		addr := common.BytesToAddress([]byte{byte(r.Intn(20))})
+		hash := common.BytesToHash([]byte{byte(r.Intn(20))})
+		switch r.Intn(3) {
+		case 0:
+			set.AddReadSet(addr, hash)
+		case 1:
+			set.AddWriteSet(addr, hash)
+		default:
+			set.AddDeltaSet(addr, hash)
+		}
+	}
+	return set
+}
+
+func TestBloomMatchesExact(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		set1, set2 := randomRWSet(r), randomRWSet(r)
+		expected := set1.HasConflict(*set2)
+		set1.BuildBloom()
+		set2.BuildBloom()
+		if set1.HasConflict(*set2) != expected {
+			t.Fatalf("bloom changed result: %s vs %s", set1.ToJsonStruct().ToString(), set2.ToJsonStruct().ToString())
+		}
+	}
+}
+
+func TestBloomAfterAdd(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	set1 := NewRWSet()
+	set1.AddReadSet(addr, BALANCE)
+	set2 := NewRWSet()
+	set2.AddReadSet(addr, NONCE)
+	set1.BuildBloom()
+	set2.BuildBloom()
+
+	// 建立过滤器之后加入的key也要被看到
+	set2.AddWriteSet(addr, BALANCE)
+	if !set1.HasConflict(*set2) {
+		t.Fatal("conflict added after BuildBloom is missed")
+	}
+}
@@ -0,0 +1,247 @@
+package accesslist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+// 二进制编码格式:
+// magic(4) | version(uint16) | len(uvarint) | RWSet...
+// RWSet: flag(1, 0表示nil) | ReadSet | WriteSet | DeltaSet
+// ALTuple: addrNum(uvarint) | {addr(20) | keyNum(uvarint) | {tag(1) [| slot(32)]}}
+// 伪key(balance/nonce等)只占一个字节的tag，storage slot才写完整的32字节
+const CodecVersion uint16 = 2
+
+var codecMagic = [4]byte{'R', 'W', 'S', 'L'}
+
+var ErrStaleCodec = errors.New("rwset codec version mismatch")
+
+const (
+	tagSlot byte = iota
+	tagCode
+	tagCodeHash
+	tagBalance
+	tagNonce
+	tagAlive
+)
+
+func hashToTag(hash common.Hash) byte {
+	switch hash {
+	case CODE:
+		return tagCode
+	case CODEHASH:
+		return tagCodeHash
+	case BALANCE:
+		return tagBalance
+	case NONCE:
+		return tagNonce
+	case ALIVE:
+		return tagAlive
+	default:
+		return tagSlot
+	}
+}
+
+func tagToHash(tag byte) (common.Hash, error) {
+	switch tag {
+	case tagCode:
+		return CODE, nil
+	case tagCodeHash:
+		return CODEHASH, nil
+	case tagBalance:
+		return BALANCE, nil
+	case tagNonce:
+		return NONCE, nil
+	case tagAlive:
+		return ALIVE, nil
+	default:
+		return common.Hash{}, fmt.Errorf("unknown key tag %d", tag)
+	}
+}
+
+// 按地址、key排序后写出，保证同样的集合编码结果一致
+func (tuple ALTuple) encode(w *bufio.Writer) error {
+	addrs := make([]common.Address, 0, len(tuple))
+	for addr := range tuple {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+
+	if err := writeUvarint(w, uint64(len(addrs))); err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if _, err := w.Write(addr[:]); err != nil {
+			return err
+		}
+		hashes := make([]common.Hash, 0, len(tuple[addr]))
+		for hash := range tuple[addr] {
+			hashes = append(hashes, hash)
+		}
+		sort.Slice(hashes, func(i, j int) bool {
+			return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+		})
+		if err := writeUvarint(w, uint64(len(hashes))); err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			tag := hashToTag(hash)
+			if err := w.WriteByte(tag); err != nil {
+				return err
+			}
+			if tag == tagSlot {
+				if _, err := w.Write(hash[:]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func decodeALTuple(r *bufio.Reader) (ALTuple, error) {
+	tuple := make(ALTuple)
+	addrNum, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < addrNum; i++ {
+		var addr common.Address
+		if _, err := io.ReadFull(r, addr[:]); err != nil {
+			return nil, err
+		}
+		keyNum, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		tuple[addr] = make(State)
+		for j := uint64(0); j < keyNum; j++ {
+			tag, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			var hash common.Hash
+			if tag == tagSlot {
+				if _, err := io.ReadFull(r, hash[:]); err != nil {
+					return nil, err
+				}
+			} else if hash, err = tagToHash(tag); err != nil {
+				return nil, err
+			}
+			tuple[addr][hash] = struct{}{}
+		}
+	}
+	return tuple, nil
+}
+
+func (RWSets RWSet) encode(w *bufio.Writer) error {
+	if err := RWSets.ReadSet.encode(w); err != nil {
+		return err
+	}
+	if err := RWSets.WriteSet.encode(w); err != nil {
+		return err
+	}
+	return RWSets.DeltaSet.encode(w)
+}
+
+func decodeRWSet(r *bufio.Reader) (*RWSet, error) {
+	readSet, err := decodeALTuple(r)
+	if err != nil {
+		return nil, err
+	}
+	writeSet, err := decodeALTuple(r)
+	if err != nil {
+		return nil, err
+	}
+	deltaSet, err := decodeALTuple(r)
+	if err != nil {
+		return nil, err
+	}
+	return &RWSet{
+		ReadSet:  readSet,
+		WriteSet: writeSet,
+		DeltaSet: deltaSet,
+	}, nil
+}
+
+// EncodeTo 将RWSetList以紧凑的二进制格式写出, nil的RWSet(预测失败的交易)也会被保留
+func (list RWSetList) EncodeTo(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	if _, err := w.Write(codecMagic[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, CodecVersion); err != nil {
+		return err
+	}
+	if err := writeUvarint(w, uint64(len(list))); err != nil {
+		return err
+	}
+	for _, set := range list {
+		if set == nil {
+			if err := w.WriteByte(0); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.WriteByte(1); err != nil {
+			return err
+		}
+		if err := set.encode(w); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// DecodeRWSetList 读取EncodeTo写出的数据，版本不一致时返回ErrStaleCodec
+func DecodeRWSetList(in io.Reader) (RWSetList, error) {
+	r := bufio.NewReader(in)
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != codecMagic {
+		return nil, fmt.Errorf("bad rwset codec magic %q", magic[:])
+	}
+	var version uint16
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != CodecVersion {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrStaleCodec, version, CodecVersion)
+	}
+	num, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	list := make(RWSetList, num)
+	for i := uint64(0); i < num; i++ {
+		flag, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if flag == 0 {
+			continue
+		}
+		if list[i], err = decodeRWSet(r); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func writeUvarint(w *bufio.Writer, x uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	_, err := w.Write(buf[:n])
+	return err
+}
@@ -0,0 +1,71 @@
+package accesslist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+func newTestList() RWSetList {
+	addr1 := common.HexToAddress("0x01")
+	addr2 := common.HexToAddress("0x02")
+
+	set1 := NewRWSet()
+	set1.AddReadSet(addr1, BALANCE)
+	set1.AddReadSet(addr1, NONCE)
+	set1.AddReadSet(addr2, common.HexToHash("0xabcd"))
+	set1.AddWriteSet(addr1, BALANCE)
+	set1.AddWriteSet(addr2, common.HexToHash("0xabcd"))
+	set1.AddDeltaSet(addr2, BALANCE)
+
+	set2 := NewRWSet()
+	set2.AddReadSet(addr2, CODE)
+	set2.AddReadSet(addr2, CODEHASH)
+	set2.AddWriteSet(addr2, ALIVE)
+
+	return RWSetList{set1, nil, set2, NewRWSet()}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	list := newTestList()
+
+	var buf bytes.Buffer
+	if err := list.EncodeTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeRWSetList(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(list) {
+		t.Fatalf("length mismatch: %d != %d", len(decoded), len(list))
+	}
+	for i := range list {
+		if list[i] == nil {
+			if decoded[i] != nil {
+				t.Fatalf("tx %d: expected nil rwset", i)
+			}
+			continue
+		}
+		if !list[i].Equal(*decoded[i]) {
+			t.Fatalf("tx %d: %s != %s", i, list[i].ToJsonStruct().ToString(), decoded[i].ToJsonStruct().ToString())
+		}
+	}
+}
+
+func TestCodecStaleVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestList().EncodeTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.Bytes()
+	binary.BigEndian.PutUint16(raw[len(codecMagic):], CodecVersion+1)
+
+	_, err := DecodeRWSetList(bytes.NewReader(raw))
+	if !errors.Is(err, ErrStaleCodec) {
+		t.Fatalf("expected ErrStaleCodec, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package accesslist
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	types2 "github.com/ledgerwatch/erigon-lib/types"
+)
+
+// ToAccessList 把读写集转换为EIP-2930的access list，
+// balance/nonce/code等伪key没有对应的storage slot，只保留地址本身
+func (RWSets RWSet) ToAccessList() types2.AccessList {
+	slots := make(map[common.Address]map[common.Hash]struct{})
+	for _, tuple := range []ALTuple{RWSets.ReadSet, RWSets.WriteSet, RWSets.DeltaSet} {
+		for addr, state := range tuple {
+			if _, ok := slots[addr]; !ok {
+				slots[addr] = make(map[common.Hash]struct{})
+			}
+			for hash := range state {
+				if GetKeyKind(hash) == KindStorage {
+					slots[addr][hash] = struct{}{}
+				}
+			}
+		}
+	}
+
+	al := make(types2.AccessList, 0, len(slots))
+	for addr, state := range slots {
+		keys := make([]common.Hash, 0, len(state))
+		for hash := range state {
+			keys = append(keys, hash)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i][:], keys[j][:]) < 0
+		})
+		al = append(al, types2.AccessTuple{Address: addr, StorageKeys: keys})
+	}
+	sort.Slice(al, func(i, j int) bool {
+		return bytes.Compare(al[i].Address[:], al[j].Address[:]) < 0
+	})
+	return al
+}
+
+// FromAccessList 用交易自带的access list生成读集骨架，
+// access list不区分读写，所有slot都记为读；没有slot的地址只声明了账户，记为对BALANCE的读
+func FromAccessList(al types2.AccessList) *RWSet {
+	RWSets := NewRWSet()
+	for _, tuple := range al {
+		if len(tuple.StorageKeys) == 0 {
+			RWSets.AddReadSet(tuple.Address, BALANCE)
+		}
+		for _, hash := range tuple.StorageKeys {
+			RWSets.AddReadSet(tuple.Address, hash)
+		}
+	}
+	return RWSets
+}
+
+// AccessListCoverage 在storage slot粒度上比较声明的access list与真实读写集，
+// 没有slot的地址单独统计
+type AccessListCoverage struct {
+	Declared int // 声明的slot数
+	Touched  int // 真实访问的slot数，读写合并
+	Covered  int // 两者的交集
+
+	DeclaredAccounts int // 只声明了地址的tuple数，同一地址只算一次
+	CoveredAccounts  int // 其中真实访问过的地址数，访问任意key都算
+}
+
+func (c *AccessListCoverage) Merge(other AccessListCoverage) {
+	c.Declared += other.Declared
+	c.Touched += other.Touched
+	c.Covered += other.Covered
+	c.DeclaredAccounts += other.DeclaredAccounts
+	c.CoveredAccounts += other.CoveredAccounts
+}
+
+// IsSubset 声明的slot都被真实访问了
+func (c AccessListCoverage) IsSubset() bool {
+	return c.Covered == c.Declared
+}
+
+// IsSuperset 真实访问的slot都被声明了，此时access list可以直接作为预测结果
+func (c AccessListCoverage) IsSuperset() bool {
+	return c.Covered == c.Touched
+}
+
+// CompareAccessList 以truth为准统计access list的覆盖情况，nil视为空集
+func CompareAccessList(al types2.AccessList, truth *RWSet) AccessListCoverage {
+	var res AccessListCoverage
+	if truth == nil {
+		truth = NewRWSet()
+	}
+	touched := make(ALTuple)
+	for _, tuple := range []ALTuple{truth.ReadSet, truth.WriteSet, truth.DeltaSet} {
+		for addr, state := range tuple {
+			for hash := range state {
+				if GetKeyKind(hash) == KindStorage {
+					touched.Add(addr, hash)
+				}
+			}
+		}
+	}
+	for _, state := range touched {
+		res.Touched += len(state)
+	}
+
+	// access list中可能重复声明同一个slot
+	declared := FromAccessList(al).ReadSet
+	for addr, state := range declared {
+		for hash := range state {
+			if GetKeyKind(hash) != KindStorage {
+				continue
+			}
+			res.Declared++
+			if touched.Contains(addr, hash) {
+				res.Covered++
+			}
+		}
+	}
+
+	// 同一地址可能另有带slot的tuple，此时已经按slot统计过
+	accounts := make(map[common.Address]bool)
+	for _, tuple := range al {
+		accounts[tuple.Address] = accounts[tuple.Address] || len(tuple.StorageKeys) > 0
+	}
+	for addr, hasSlots := range accounts {
+		if hasSlots {
+			continue
+		}
+		res.DeclaredAccounts++
+		if len(truth.ReadSet[addr]) > 0 || len(truth.WriteSet[addr]) > 0 || len(truth.DeltaSet[addr]) > 0 {
+			res.CoveredAccounts++
+		}
+	}
+	return res
+}
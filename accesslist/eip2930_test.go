@@ -0,0 +1,79 @@
+package accesslist
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	types2 "github.com/ledgerwatch/erigon-lib/types"
+)
+
+func TestAccessListRoundTrip(t *testing.T) {
+	addr1 := common.HexToAddress("0x01")
+	addr2 := common.HexToAddress("0x02")
+	slot1 := common.HexToHash("0x01")
+	slot2 := common.HexToHash("0x02")
+
+	set := NewRWSet()
+	set.AddReadSet(addr1, BALANCE)
+	set.AddReadSet(addr2, slot2)
+	set.AddWriteSet(addr2, slot1)
+	set.AddDeltaSet(addr1, BALANCE)
+
+	al := set.ToAccessList()
+	if len(al) != 2 {
+		t.Fatalf("expected 2 tuples, got %d", len(al))
+	}
+	// 只有伪key的地址保留为空tuple
+	if al[0].Address != addr1 || len(al[0].StorageKeys) != 0 {
+		t.Fatalf("unexpected tuple %v", al[0])
+	}
+	if al[1].Address != addr2 || len(al[1].StorageKeys) != 2 || al[1].StorageKeys[0] != slot1 || al[1].StorageKeys[1] != slot2 {
+		t.Fatalf("unexpected tuple %v", al[1])
+	}
+
+	// 空tuple还原为对BALANCE的读
+	skeleton := FromAccessList(al)
+	if !skeleton.ReadSet.Contains(addr1, BALANCE) || len(skeleton.ReadSet[addr1]) != 1 ||
+		!skeleton.ReadSet.Contains(addr2, slot1) || !skeleton.ReadSet.Contains(addr2, slot2) || len(skeleton.ReadSet[addr2]) != 2 {
+		t.Fatalf("unexpected skeleton %s", skeleton.ToJsonStruct().ToString())
+	}
+	if len(skeleton.WriteSet) != 0 || len(skeleton.DeltaSet) != 0 {
+		t.Fatal("skeleton should only contain reads")
+	}
+}
+
+func TestCompareAccessList(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	slot1 := common.HexToHash("0x01")
+	slot2 := common.HexToHash("0x02")
+	slot3 := common.HexToHash("0x03")
+
+	truth := NewRWSet()
+	truth.AddReadSet(addr, slot1)
+	truth.AddWriteSet(addr, slot2)
+	truth.AddReadSet(addr, BALANCE)
+
+	al := types2.AccessList{{Address: addr, StorageKeys: []common.Hash{slot1, slot2, slot1}}}
+	c := CompareAccessList(al, truth)
+	if c.Declared != 2 || c.Touched != 2 || c.Covered != 2 || !c.IsSubset() || !c.IsSuperset() {
+		t.Fatalf("unexpected coverage %+v", c)
+	}
+
+	al = types2.AccessList{{Address: addr, StorageKeys: []common.Hash{slot1, slot3}}}
+	c = CompareAccessList(al, truth)
+	if c.Declared != 2 || c.Covered != 1 || c.IsSubset() || c.IsSuperset() {
+		t.Fatalf("unexpected coverage %+v", c)
+	}
+
+	// 只有地址的tuple单独统计，不计入slot；addr另有带slot的tuple时不算
+	other := common.HexToAddress("0x02")
+	al = types2.AccessList{{Address: addr}, {Address: addr, StorageKeys: []common.Hash{slot1}}, {Address: other}, {Address: other}}
+	c = CompareAccessList(al, truth)
+	if c.Declared != 1 || c.Covered != 1 || c.DeclaredAccounts != 1 || c.CoveredAccounts != 0 {
+		t.Fatalf("unexpected coverage %+v", c)
+	}
+	c = CompareAccessList(types2.AccessList{{Address: addr}}, truth)
+	if c.Declared != 0 || c.DeclaredAccounts != 1 || c.CoveredAccounts != 1 || !c.IsSubset() || c.IsSuperset() {
+		t.Fatalf("unexpected coverage %+v", c)
+	}
+}
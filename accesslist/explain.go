@@ -0,0 +1,199 @@
+package accesslist
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+// ConflictType 是位掩码，同一个key上可能同时存在多种冲突
+// 以交易顺序为准: RAW 后者读前者写的key, WAR 后者写前者读的key, WAW 两者都写
+// 加法写在这里算作写，但两个加法写之间不冲突
+type ConflictType uint8
+
+const (
+	RAW ConflictType = 1 << iota
+	WAR
+	WAW
+)
+
+func (t ConflictType) String() string {
+	names := make([]string, 0, 3)
+	if t&RAW != 0 {
+		names = append(names, "RAW")
+	}
+	if t&WAR != 0 {
+		names = append(names, "WAR")
+	}
+	if t&WAW != 0 {
+		names = append(names, "WAW")
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, "|")
+}
+
+// Conflict 一条边上的一个冲突key
+type Conflict struct {
+	Addr common.Address
+	Hash common.Hash
+	Type ConflictType
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s.%s:%s", c.Addr.Hex(), DecodeHash(c.Hash), c.Type)
+}
+
+// TxPair 按交易顺序排列的一对交易, From < To
+type TxPair struct {
+	From uint
+	To   uint
+}
+
+func NewTxPair(tx1, tx2 uint) TxPair {
+	return TxPair{From: min(tx1, tx2), To: max(tx1, tx2)}
+}
+
+func (RWSets RWSet) writes(addr common.Address, hash common.Hash) bool {
+	return RWSets.WriteSet.Contains(addr, hash) || RWSets.DeltaSet.Contains(addr, hash)
+}
+
+// 两笔交易在同一个key上的冲突类型，before在前
+func conflictType(beforeRead, beforeWrite, beforeDelta, afterRead, afterWrite, afterDelta bool) ConflictType {
+	var t ConflictType
+	if (beforeWrite || beforeDelta) && afterRead {
+		t |= RAW
+	}
+	if beforeRead && (afterWrite || afterDelta) {
+		t |= WAR
+	}
+	if (beforeWrite && (afterWrite || afterDelta)) || (beforeDelta && afterWrite) {
+		t |= WAW
+	}
+	return t
+}
+
+// Explain 列出RWSets(在前的交易)与later(在后的交易)之间所有冲突的key
+func (RWSets RWSet) Explain(later RWSet) []Conflict {
+	conflicts := make([]Conflict, 0)
+	check := func(addr common.Address, hash common.Hash) {
+		t := conflictType(
+			RWSets.ReadSet.Contains(addr, hash), RWSets.WriteSet.Contains(addr, hash), RWSets.DeltaSet.Contains(addr, hash),
+			later.ReadSet.Contains(addr, hash), later.WriteSet.Contains(addr, hash), later.DeltaSet.Contains(addr, hash),
+		)
+		if t != 0 {
+			conflicts = append(conflicts, Conflict{Addr: addr, Hash: hash, Type: t})
+		}
+	}
+	// 冲突的key一定被至少一方写过, 遍历双方的写集即可, 两边都写的key只记一次
+	for _, tuple := range []ALTuple{RWSets.WriteSet, RWSets.DeltaSet} {
+		for addr, state := range tuple {
+			for hash := range state {
+				check(addr, hash)
+			}
+		}
+	}
+	for _, tuple := range []ALTuple{later.WriteSet, later.DeltaSet} {
+		for addr, state := range tuple {
+			for hash := range state {
+				if !RWSets.writes(addr, hash) {
+					check(addr, hash)
+				}
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if c := bytes.Compare(conflicts[i].Addr[:], conflicts[j].Addr[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(conflicts[i].Hash[:], conflicts[j].Hash[:]) < 0
+	})
+	return conflicts
+}
+
+func containsTx(txIds []uint, txId uint) bool {
+	pos := sort.Search(len(txIds), func(i int) bool {
+		return txIds[i] >= txId
+	})
+	return pos < len(txIds) && txIds[pos] == txId
+}
+
+// Explain 返回tx1与tx2之间的冲突，按key id排序
+func (rw *RwAccessedBy) Explain(tx1, tx2 uint) []Conflict {
+	pair := NewTxPair(tx1, tx2)
+	conflicts := make([]Conflict, 0)
+	for keyId := 0; keyId < rw.Keys.Len(); keyId++ {
+		rTxs := rw.ReadBy.TxIds(uint32(keyId))
+		wTxs := rw.WriteBy.TxIds(uint32(keyId))
+		dTxs := rw.DeltaBy.TxIds(uint32(keyId))
+		t := conflictType(
+			containsTx(rTxs, pair.From), containsTx(wTxs, pair.From), containsTx(dTxs, pair.From),
+			containsTx(rTxs, pair.To), containsTx(wTxs, pair.To), containsTx(dTxs, pair.To),
+		)
+		if t != 0 {
+			key := rw.Keys.Key(uint32(keyId))
+			conflicts = append(conflicts, Conflict{Addr: key.Addr, Hash: key.Hash, Type: t})
+		}
+	}
+	return conflicts
+}
+
+// ConflictPairs 计算同一个key上读者、写者、加法写者两两之间的冲突
+func ConflictPairs(rTxs, wTxs, dTxs []uint) map[TxPair]ConflictType {
+	pairs := make(map[TxPair]ConflictType)
+	mark := func(before, after uint, t ConflictType) {
+		if before == after {
+			return
+		}
+		if before > after {
+			before, after = after, before
+			// 调换顺序后读写方向也要调换
+			switch t {
+			case RAW:
+				t = WAR
+			case WAR:
+				t = RAW
+			}
+		}
+		pairs[TxPair{From: before, To: after}] |= t
+	}
+	for i := 0; i < len(wTxs); i++ {
+		for j := i + 1; j < len(wTxs); j++ {
+			mark(wTxs[i], wTxs[j], WAW)
+		}
+		for _, dTx := range dTxs {
+			mark(wTxs[i], dTx, WAW)
+		}
+		for _, rTx := range rTxs {
+			mark(wTxs[i], rTx, RAW)
+		}
+	}
+	for _, dTx := range dTxs {
+		for _, rTx := range rTxs {
+			mark(dTx, rTx, RAW)
+		}
+	}
+	return pairs
+}
+
+// KeyConflicts 某一个key贡献的所有冲突边
+func (rw *RwAccessedBy) KeyConflicts(keyId uint32) map[TxPair]ConflictType {
+	return ConflictPairs(rw.ReadBy.TxIds(keyId), rw.WriteBy.TxIds(keyId), rw.DeltaBy.TxIds(keyId))
+}
+
+// ExplainAll 一次遍历所有key，给出每一对冲突交易的冲突列表，
+// 得到的TxPair集合正好是GenerateUndiGraph的边，也是GenerateDiGraph的边(From -> To)
+func (rw *RwAccessedBy) ExplainAll() map[TxPair][]Conflict {
+	res := make(map[TxPair][]Conflict)
+	for keyId := 0; keyId < rw.Keys.Len(); keyId++ {
+		key := rw.Keys.Key(uint32(keyId))
+		for pair, t := range rw.KeyConflicts(uint32(keyId)) {
+			res[pair] = append(res[pair], Conflict{Addr: key.Addr, Hash: key.Hash, Type: t})
+		}
+	}
+	return res
+}
@@ -0,0 +1,51 @@
+package accesslist
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+func TestExplain(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	slot := common.HexToHash("0x01")
+
+	sets := RWSetList{NewRWSet(), NewRWSet(), NewRWSet()}
+	// tx0 写slot, tx1 读slot并写slot, tx2 读balance, tx0/tx1 对balance做加法
+	sets[0].AddWriteSet(addr, slot)
+	sets[0].AddDeltaSet(addr, BALANCE)
+	sets[1].AddReadSet(addr, slot)
+	sets[1].AddWriteSet(addr, slot)
+	sets[1].AddDeltaSet(addr, BALANCE)
+	sets[2].AddReadSet(addr, BALANCE)
+
+	rw := NewRwAccessedBy()
+	for i, set := range sets {
+		rw.Add(set, uint(i))
+	}
+	all := rw.ExplainAll()
+
+	check := func(tx1, tx2 uint, want map[common.Hash]ConflictType) {
+		pair := NewTxPair(tx1, tx2)
+		lists := [][]Conflict{all[pair], rw.Explain(tx1, tx2), sets[pair.From].Explain(*sets[pair.To])}
+		for _, conflicts := range lists {
+			if len(conflicts) != len(want) {
+				t.Fatalf("pair %v: got %v, want %v", pair, conflicts, want)
+			}
+			for _, c := range conflicts {
+				if want[c.Hash] != c.Type {
+					t.Fatalf("pair %v: got %v, want %v", pair, conflicts, want)
+				}
+			}
+		}
+	}
+	check(0, 1, map[common.Hash]ConflictType{slot: RAW | WAW})
+	check(2, 0, map[common.Hash]ConflictType{BALANCE: RAW})
+	check(1, 2, map[common.Hash]ConflictType{BALANCE: RAW})
+	if len(all) != 3 {
+		t.Fatalf("unexpected pairs: %v", all)
+	}
+	if got := (Conflict{Addr: addr, Hash: BALANCE, Type: RAW | WAR}).String(); got != addr.Hex()+".balance:RAW|WAR" {
+		t.Fatalf("unexpected string %s", got)
+	}
+}
@@ -0,0 +1,113 @@
+package accesslist
+
+import (
+	"sort"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+// AccessKey 读写集中的一个(address, key)
+type AccessKey struct {
+	Addr common.Address
+	Hash common.Hash
+}
+
+// KeyInterner 把一个批次内出现过的(address, key)映射为连续的uint32，
+// 之后建图只需要比较整数而不用反复查嵌套的map
+type KeyInterner struct {
+	ids  map[AccessKey]uint32
+	keys []AccessKey
+}
+
+func NewKeyInterner() *KeyInterner {
+	return &KeyInterner{
+		ids:  make(map[AccessKey]uint32),
+		keys: make([]AccessKey, 0),
+	}
+}
+
+// Intern 返回key对应的id，第一次出现时分配新的id
+func (in *KeyInterner) Intern(addr common.Address, hash common.Hash) uint32 {
+	key := AccessKey{Addr: addr, Hash: hash}
+	if id, ok := in.ids[key]; ok {
+		return id
+	}
+	id := uint32(len(in.keys))
+	in.ids[key] = id
+	in.keys = append(in.keys, key)
+	return id
+}
+
+func (in *KeyInterner) Lookup(addr common.Address, hash common.Hash) (uint32, bool) {
+	id, ok := in.ids[AccessKey{Addr: addr, Hash: hash}]
+	return id, ok
+}
+
+func (in *KeyInterner) Key(id uint32) AccessKey {
+	return in.keys[id]
+}
+
+func (in *KeyInterner) Len() int {
+	return len(in.keys)
+}
+
+func (in *KeyInterner) Copy() *KeyInterner {
+	newIn := &KeyInterner{
+		ids:  make(map[AccessKey]uint32, len(in.ids)),
+		keys: make([]AccessKey, len(in.keys)),
+	}
+	copy(newIn.keys, in.keys)
+	for key, id := range in.ids {
+		newIn.ids[key] = id
+	}
+	return newIn
+}
+
+// 返回升序排列的id数组
+func (tuple ALTuple) intern(keys *KeyInterner) []uint32 {
+	ids := make([]uint32, 0, len(tuple))
+	for addr, state := range tuple {
+		for hash := range state {
+			ids = append(ids, keys.Intern(addr, hash))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Intern 为读写集生成有序的id数组；之后再Add新的key会清掉这些id，需要重新Intern。
+// 同一个RWSet再次Intern到别的KeyInterner时会覆盖之前的id
+func (RWSets *RWSet) Intern(keys *KeyInterner) {
+	RWSets.ReadIds = RWSets.ReadSet.intern(keys)
+	RWSets.WriteIds = RWSets.WriteSet.intern(keys)
+	RWSets.DeltaIds = RWSets.DeltaSet.intern(keys)
+	RWSets.keys = keys
+}
+
+// 两个有序id数组是否有交集
+func idsIntersect(a, b []uint32) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			return true
+		} else if a[i] < b[j] {
+			i++
+		} else {
+			j++
+		}
+	}
+	return false
+}
+
+// 两个读写集都Intern到同一个KeyInterner时，用有序数组归并判断冲突
+func (RWSets RWSet) hasConflictById(other RWSet) bool {
+	return idsIntersect(RWSets.ReadIds, other.WriteIds) ||
+		idsIntersect(RWSets.ReadIds, other.DeltaIds) ||
+		idsIntersect(RWSets.WriteIds, other.WriteIds) ||
+		idsIntersect(RWSets.WriteIds, other.ReadIds) ||
+		idsIntersect(RWSets.WriteIds, other.DeltaIds) ||
+		idsIntersect(RWSets.DeltaIds, other.WriteIds) ||
+		idsIntersect(RWSets.DeltaIds, other.ReadIds)
+}
@@ -0,0 +1,156 @@
+package accesslist
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+func TestAccessedBySorted(t *testing.T) {
+	accessedBy := NewAccessedBy()
+	for _, txId := range []uint{5, 1, 3, 3, 9, 0} {
+		accessedBy.Add(2, txId)
+	}
+	want := []uint{0, 1, 3, 5, 9}
+	got := accessedBy.TxIds(2)
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if len(accessedBy.TxIds(0)) != 0 || len(accessedBy.TxIds(100)) != 0 {
+		t.Fatal("unexpected txIds for unused key")
+	}
+}
+
+func TestHasConflictById(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	slot := common.HexToHash("0x01")
+	sets := make(RWSetList, 0)
+	for i := 0; i < 4; i++ {
+		sets = append(sets, NewRWSet())
+	}
+	sets[0].AddReadSet(addr, slot)
+	sets[1].AddWriteSet(addr, slot)
+	sets[2].AddDeltaSet(addr, BALANCE)
+	sets[3].AddDeltaSet(addr, BALANCE)
+	sets[3].AddReadSet(addr, NONCE)
+
+	// 未Intern时走map, Intern之后走有序数组，结果应该一致
+	want := make([][]bool, len(sets))
+	for i := range sets {
+		want[i] = make([]bool, len(sets))
+		for j := range sets {
+			want[i][j] = sets[i].HasConflict(*sets[j])
+		}
+	}
+	rw := NewRwAccessedBy()
+	for i, set := range sets {
+		rw.Add(set, uint(i))
+	}
+	for i := range sets {
+		for j := range sets {
+			if sets[i].HasConflict(*sets[j]) != want[i][j] {
+				t.Fatalf("conflict mismatch between %d and %d", i, j)
+			}
+		}
+	}
+	id, ok := rw.Keys.Lookup(addr, slot)
+	if !ok || len(rw.ReadBy.TxIds(id)) != 1 || len(rw.WriteBy.TxIds(id)) != 1 {
+		t.Fatal("unexpected accessedBy for slot")
+	}
+}
+
+// Intern之后再加入的key也要参与冲突判断
+func TestAddAfterIntern(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	slot := common.HexToHash("0x01")
+	for _, add := range []func(set *RWSet){
+		func(set *RWSet) { set.AddWriteSet(addr, slot) },
+		func(set *RWSet) { set.AddDeltaSet(addr, slot) },
+	} {
+		reader := NewRWSet()
+		reader.AddReadSet(addr, slot)
+		other := NewRWSet()
+		other.AddReadSet(addr, NONCE)
+		rw := NewRwAccessedBy()
+		rw.Add(reader, 0)
+		rw.Add(other, 1)
+		if reader.HasConflict(*other) {
+			t.Fatal("unexpected conflict before add")
+		}
+
+		add(other)
+		if !reader.HasConflict(*other) || !other.HasConflict(*reader) {
+			t.Fatal("key added after Intern was ignored")
+		}
+		// 重新加入RwAccessedBy时会重新Intern
+		rw.Add(other, 1)
+		if len(other.ReadIds)+len(other.WriteIds)+len(other.DeltaIds) != 2 || !reader.HasConflict(*other) {
+			t.Fatal("re-intern lost the added key")
+		}
+		id, _ := rw.Keys.Lookup(addr, slot)
+		if len(rw.WriteBy.TxIds(id))+len(rw.DeltaBy.TxIds(id)) != 1 {
+			t.Fatal("accessedBy missing the added key")
+		}
+	}
+
+	// 已有的key不会清掉id
+	set := NewRWSet()
+	set.AddReadSet(addr, slot)
+	set.Intern(NewKeyInterner())
+	set.AddReadSet(addr, slot)
+	if set.keys == nil || len(set.ReadIds) != 1 {
+		t.Fatal("re-adding an existing key dropped the ids")
+	}
+}
+
+// 一个区块规模的读写集，每笔交易从keyNum个热点不均匀的key中取若干个
+func benchmarkSets(txNum, keyNum int) RWSetList {
+	r := rand.New(rand.NewSource(1))
+	sets := make(RWSetList, txNum)
+	for i := range sets {
+		sets[i] = NewRWSet()
+		for j := 0; j < 4+r.Intn(30); j++ {
+			key := r.Intn(keyNum) * r.Intn(keyNum) / keyNum
+			addr := common.BytesToAddress([]byte{byte(key % 16)})
+			hash := common.BytesToHash([]byte{byte(key >> 8), byte(key)})
+			if r.Intn(3) == 0 {
+				sets[i].AddWriteSet(addr, hash)
+			} else {
+				sets[i].AddReadSet(addr, hash)
+			}
+		}
+	}
+	return sets
+}
+
+func benchmarkAllPairs(b *testing.B, sets RWSetList) {
+	for i := 0; i < b.N; i++ {
+		for x := range sets {
+			for y := x + 1; y < len(sets); y++ {
+				sets[x].HasConflict(*sets[y])
+			}
+		}
+	}
+}
+
+// 建图时两两比较的开销，对比按嵌套map比较和Intern之后按有序id归并
+func BenchmarkHasConflict(b *testing.B) {
+	b.Run("map", func(b *testing.B) {
+		benchmarkAllPairs(b, benchmarkSets(300, 2000))
+	})
+	b.Run("interned", func(b *testing.B) {
+		sets := benchmarkSets(300, 2000)
+		rw := NewRwAccessedBy()
+		for i, set := range sets {
+			rw.Add(set, uint(i))
+		}
+		b.ResetTimer()
+		benchmarkAllPairs(b, sets)
+	})
+}
@@ -37,32 +37,121 @@ func (tuple ALTuple) Contains(addr common.Address, hash common.Hash) bool {
 	return ok
 }
 
+// Equal 地址集合相同，并且每个地址下的key集合相同
+func (tuple ALTuple) Equal(other ALTuple) bool {
+	if len(tuple) != len(other) {
+		return false
+	}
+	for addr, state := range tuple {
+		otherState, ok := other[addr]
+		if !ok || len(state) != len(otherState) {
+			return false
+		}
+		for hash := range state {
+			if _, ok := otherState[hash]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (tuple ALTuple) Remove(addr common.Address, hash common.Hash) {
+	if _, ok := tuple[addr]; !ok {
+		return
+	}
+	delete(tuple[addr], hash)
+	if len(tuple[addr]) == 0 {
+		delete(tuple, addr)
+	}
+}
+
+// DeltaSet 记录只做了可交换加法的写(如AddBalance)，这类写之间互不冲突，
+// 只与同一个key上的读和普通写冲突
+// ReadIds/WriteIds/DeltaIds 是Intern之后的有序key id，只在同一个KeyInterner内有意义
+// bloom 由BuildBloom生成，为nil时HasConflict直接做精确判断
 type RWSet struct {
 	ReadSet  ALTuple
 	WriteSet ALTuple
+	DeltaSet ALTuple
+
+	ReadIds  []uint32
+	WriteIds []uint32
+	DeltaIds []uint32
+	keys     *KeyInterner
+	bloom    *rwBloom
 }
 
 func NewRWSet() *RWSet {
 	return &RWSet{
 		ReadSet:  make(ALTuple),
 		WriteSet: make(ALTuple),
+		DeltaSet: make(ALTuple),
+	}
+}
+
+// 加入新key后之前Intern得到的id不再完整，清掉后HasConflict回到按map比较，
+// 再次Intern(如RwAccessedBy.Add)时重新生成
+func (RWSets *RWSet) dropIds() {
+	if RWSets.keys == nil {
+		return
 	}
+	RWSets.ReadIds = nil
+	RWSets.WriteIds = nil
+	RWSets.DeltaIds = nil
+	RWSets.keys = nil
 }
 
-func (RWSets RWSet) AddReadSet(addr common.Address, hash common.Hash) {
+func (RWSets *RWSet) AddReadSet(addr common.Address, hash common.Hash) {
+	if RWSets.ReadSet.Contains(addr, hash) {
+		return
+	}
 	RWSets.ReadSet.Add(addr, hash)
+	RWSets.dropIds()
+	if RWSets.bloom != nil {
+		RWSets.bloom.read.Add(addr, hash)
+	}
 }
 
-func (RWSets RWSet) AddWriteSet(addr common.Address, hash common.Hash) {
+// 普通写会覆盖之前的加法，此时这个key只按普通写处理
+func (RWSets *RWSet) AddWriteSet(addr common.Address, hash common.Hash) {
+	if RWSets.WriteSet.Contains(addr, hash) {
+		return
+	}
 	RWSets.WriteSet.Add(addr, hash)
+	RWSets.DeltaSet.Remove(addr, hash)
+	RWSets.dropIds()
+	if RWSets.bloom != nil {
+		RWSets.bloom.write.Add(addr, hash)
+	}
+}
+
+func (RWSets *RWSet) AddDeltaSet(addr common.Address, hash common.Hash) {
+	if RWSets.WriteSet.Contains(addr, hash) || RWSets.DeltaSet.Contains(addr, hash) {
+		return
+	}
+	RWSets.DeltaSet.Add(addr, hash)
+	RWSets.dropIds()
+	if RWSets.bloom != nil {
+		RWSets.bloom.delta.Add(addr, hash)
+	}
 }
 
 func (RWSets RWSet) HasConflict(other RWSet) bool {
+	if RWSets.bloom != nil && other.bloom != nil && !RWSets.bloom.mayConflict(other.bloom) {
+		return false
+	}
+	if RWSets.keys != nil && RWSets.keys == other.keys {
+		return RWSets.hasConflictById(other)
+	}
 	for addr, state := range RWSets.ReadSet {
 		for hash := range state {
 			if other.WriteSet.Contains(addr, hash) {
 				return true
 			}
+			if other.DeltaSet.Contains(addr, hash) {
+				return true
+			}
 		}
 	}
 	for addr, state := range RWSets.WriteSet {
@@ -73,36 +162,30 @@ func (RWSets RWSet) HasConflict(other RWSet) bool {
 			if other.ReadSet.Contains(addr, hash) {
 				return true
 			}
-		}
-	}
-	return false
-}
-
-func (RWSets RWSet) Equal(other RWSet) bool {
-	if len(RWSets.ReadSet) != len(other.ReadSet) {
-		return false
-	}
-	if len(RWSets.WriteSet) != len(other.WriteSet) {
-		return false
-	}
-
-	for addr, state := range RWSets.ReadSet {
-		for hash := range state {
-			if !other.ReadSet.Contains(addr, hash) {
-				return false
+			if other.DeltaSet.Contains(addr, hash) {
+				return true
 			}
 		}
 	}
-
-	for addr, state := range RWSets.WriteSet {
+	// 加法与加法之间不冲突
+	for addr, state := range RWSets.DeltaSet {
 		for hash := range state {
-			if !other.WriteSet.Contains(addr, hash) {
-				return false
+			if other.WriteSet.Contains(addr, hash) {
+				return true
+			}
+			if other.ReadSet.Contains(addr, hash) {
+				return true
 			}
 		}
 	}
+	return false
+}
 
-	return true
+// Equal 两个读写集的每个地址下的key完全相同
+func (RWSets RWSet) Equal(other RWSet) bool {
+	return RWSets.ReadSet.Equal(other.ReadSet) &&
+		RWSets.WriteSet.Equal(other.WriteSet) &&
+		RWSets.DeltaSet.Equal(other.DeltaSet)
 }
 
 func DecodeHash(hash common.Hash) string {
@@ -142,6 +225,7 @@ func encodeHash(str string) common.Hash {
 func (RWSets RWSet) ToJsonStruct() RWSetJson {
 	readAL := make(map[common.Address][]string)
 	writeAL := make(map[common.Address][]string)
+	deltaAL := make(map[common.Address][]string)
 
 	for addr, state := range RWSets.ReadSet {
 		for hash := range state {
@@ -155,15 +239,34 @@ func (RWSets RWSet) ToJsonStruct() RWSetJson {
 		}
 	}
 
+	for addr, state := range RWSets.DeltaSet {
+		for hash := range state {
+			deltaAL[addr] = append(deltaAL[addr], DecodeHash(hash))
+		}
+	}
+
+	// 排序保证两次运行dump出来的结果可以直接diff
+	for _, keys := range readAL {
+		sort.Strings(keys)
+	}
+	for _, keys := range writeAL {
+		sort.Strings(keys)
+	}
+	for _, keys := range deltaAL {
+		sort.Strings(keys)
+	}
+
 	return RWSetJson{
 		ReadSet:  readAL,
 		WriteSet: writeAL,
+		DeltaSet: deltaAL,
 	}
 }
 
 type RWSetJson struct {
 	ReadSet  map[common.Address][]string `json:"readSet"`
 	WriteSet map[common.Address][]string `json:"writeSet"`
+	DeltaSet map[common.Address][]string `json:"deltaSet,omitempty"`
 }
 
 func (rwj RWSetJson) ToString() string {
@@ -171,86 +274,160 @@ func (rwj RWSetJson) ToString() string {
 	return string(b)
 }
 
-// readBy / writeBy 所依赖的数据结构
-type AccessedBy map[common.Address]map[common.Hash]map[uint]struct{}
+// FromJson 是ToJsonStruct的逆过程，"balance"/"nonce"等伪key会被还原成对应的常量
+func (RWSets *RWSet) FromJson(rwj RWSetJson) {
+	RWSets.ReadSet = make(ALTuple)
+	RWSets.WriteSet = make(ALTuple)
+	RWSets.DeltaSet = make(ALTuple)
+	for addr, keys := range rwj.ReadSet {
+		for _, key := range keys {
+			RWSets.ReadSet.Add(addr, encodeHash(key))
+		}
+	}
+	for addr, keys := range rwj.WriteSet {
+		for _, key := range keys {
+			RWSets.WriteSet.Add(addr, encodeHash(key))
+		}
+	}
+	for addr, keys := range rwj.DeltaSet {
+		for _, key := range keys {
+			RWSets.DeltaSet.Add(addr, encodeHash(key))
+		}
+	}
+}
 
-func NewAccessedBy() AccessedBy {
-	return make(map[common.Address]map[common.Hash]map[uint]struct{})
+func (RWSets RWSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(RWSets.ToJsonStruct())
 }
 
-func (accessedBy AccessedBy) Add(addr common.Address, hash common.Hash, txID uint) {
-	if _, ok := accessedBy[addr]; !ok {
-		accessedBy[addr] = make(map[common.Hash]map[uint]struct{})
+func (RWSets *RWSet) UnmarshalJSON(data []byte) error {
+	var rwj RWSetJson
+	if err := json.Unmarshal(data, &rwj); err != nil {
+		return err
 	}
-	if _, ok := accessedBy[addr][hash]; !ok {
-		accessedBy[addr][hash] = make(map[uint]struct{})
+	RWSets.FromJson(rwj)
+	return nil
+}
+
+// RWSetList的元素是*RWSet, 预测失败的交易对应null
+func (list RWSetList) ToJsonStruct() []*RWSetJson {
+	ret := make([]*RWSetJson, len(list))
+	for i, set := range list {
+		if set == nil {
+			continue
+		}
+		rwj := set.ToJsonStruct()
+		ret[i] = &rwj
 	}
-	accessedBy[addr][hash][txID] = struct{}{}
+	return ret
 }
 
-// 从小到大返回一个记录被访问的txID的数组
-func (accessedBy AccessedBy) TxIds(addr common.Address, hash common.Hash) []uint {
-	txIds := make([]uint, 0)
-
-	if _, ok := accessedBy[addr]; !ok {
-		return txIds
-	} else if _, ok := accessedBy[addr][hash]; !ok {
-		return txIds
-	} else {
-		for txID := range accessedBy[addr][hash] {
-			txIds = append(txIds, txID)
+func (list *RWSetList) FromJson(rwjs []*RWSetJson) {
+	*list = make(RWSetList, len(rwjs))
+	for i, rwj := range rwjs {
+		if rwj == nil {
+			continue
 		}
+		(*list)[i] = NewRWSet()
+		(*list)[i].FromJson(*rwj)
+	}
+}
+
+func (list *RWSetList) UnmarshalJSON(data []byte) error {
+	var rwjs []*RWSetJson
+	if err := json.Unmarshal(data, &rwjs); err != nil {
+		return err
 	}
+	list.FromJson(rwjs)
+	return nil
+}
+
+// readBy / writeBy 所依赖的数据结构
+// 下标是KeyInterner分配的key id，每个列表都按txID升序保存
+type AccessedBy [][]uint
+
+func NewAccessedBy() AccessedBy {
+	return make(AccessedBy, 0)
+}
 
-	sort.Slice(txIds, func(i, j int) bool {
-		return txIds[i] < txIds[j]
+func (accessedBy *AccessedBy) Add(keyId uint32, txID uint) {
+	for int(keyId) >= len(*accessedBy) {
+		*accessedBy = append(*accessedBy, nil)
+	}
+	txIds := (*accessedBy)[keyId]
+	// 通常txID是递增加入的，直接append
+	if len(txIds) == 0 || txIds[len(txIds)-1] < txID {
+		(*accessedBy)[keyId] = append(txIds, txID)
+		return
+	}
+	pos := sort.Search(len(txIds), func(i int) bool {
+		return txIds[i] >= txID
 	})
-	return txIds
+	if txIds[pos] == txID {
+		return
+	}
+	txIds = append(txIds, 0)
+	copy(txIds[pos+1:], txIds[pos:])
+	txIds[pos] = txID
+	(*accessedBy)[keyId] = txIds
+}
+
+// 从小到大返回一个记录被访问的txID的数组，返回的是内部数组，调用者不能修改
+func (accessedBy AccessedBy) TxIds(keyId uint32) []uint {
+	if int(keyId) >= len(accessedBy) {
+		return nil
+	}
+	return accessedBy[keyId]
+}
+
+func (accessedBy AccessedBy) Copy() AccessedBy {
+	newAccessedBy := make(AccessedBy, len(accessedBy))
+	for keyId, txIds := range accessedBy {
+		newAccessedBy[keyId] = append([]uint(nil), txIds...)
+	}
+	return newAccessedBy
 }
 
 type RwAccessedBy struct {
+	Keys    *KeyInterner
 	ReadBy  AccessedBy
 	WriteBy AccessedBy
+	DeltaBy AccessedBy
 }
 
 func NewRwAccessedBy() *RwAccessedBy {
 	return &RwAccessedBy{
+		Keys:    NewKeyInterner(),
 		ReadBy:  NewAccessedBy(),
 		WriteBy: NewAccessedBy(),
+		DeltaBy: NewAccessedBy(),
 	}
 }
 
+// Add 会把set Intern到rw.Keys上
 func (rw *RwAccessedBy) Add(set *RWSet, txId uint) {
 	if set == nil {
 		return
 	}
-	for addr, state := range set.ReadSet {
-		for hash := range state {
-			rw.ReadBy.Add(addr, hash, txId)
-		}
+	if set.keys != rw.Keys {
+		set.Intern(rw.Keys)
 	}
-	for addr, state := range set.WriteSet {
-		for hash := range state {
-			rw.WriteBy.Add(addr, hash, txId)
-		}
+	for _, keyId := range set.ReadIds {
+		rw.ReadBy.Add(keyId, txId)
+	}
+	for _, keyId := range set.WriteIds {
+		rw.WriteBy.Add(keyId, txId)
+	}
+	for _, keyId := range set.DeltaIds {
+		rw.DeltaBy.Add(keyId, txId)
 	}
 }
 
 func (rw *RwAccessedBy) Copy() *RwAccessedBy {
-	newRw := NewRwAccessedBy()
-	for addr, hashMap := range rw.ReadBy {
-		for hash, txMap := range hashMap {
-			for txId := range txMap {
-				newRw.ReadBy.Add(addr, hash, txId)
-			}
-		}
-	}
-	for addr, hashMap := range rw.WriteBy {
-		for hash, txMap := range hashMap {
-			for txId := range txMap {
-				newRw.WriteBy.Add(addr, hash, txId)
-			}
-		}
+	return &RwAccessedBy{
+		Keys:    rw.Keys.Copy(),
+		ReadBy:  rw.ReadBy.Copy(),
+		WriteBy: rw.WriteBy.Copy(),
+		DeltaBy: rw.DeltaBy.Copy(),
 	}
-	return newRw
 }
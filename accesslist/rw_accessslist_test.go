@@ -0,0 +1,111 @@
+package accesslist
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+func TestJsonRoundTrip(t *testing.T) {
+	list := newTestList()
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded RWSetList
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(list) {
+		t.Fatalf("length mismatch: %d != %d", len(decoded), len(list))
+	}
+	for i := range list {
+		if list[i] == nil {
+			if decoded[i] != nil {
+				t.Fatalf("tx %d: expected nil rwset", i)
+			}
+			continue
+		}
+		if !list[i].Equal(*decoded[i]) {
+			t.Fatalf("tx %d: %s != %s", i, list[i].ToJsonStruct().ToString(), decoded[i].ToJsonStruct().ToString())
+		}
+	}
+}
+
+func TestRWSetEqual(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	slot := common.HexToHash("0x01")
+	base := newTestList()[0]
+	if !base.Equal(*newTestList()[0]) {
+		t.Fatal("identical rwsets not equal")
+	}
+
+	// 同一个地址下多一个key、换一个key、读写互换，地址数都不变
+	extra := newTestList()[0]
+	extra.AddReadSet(addr, slot)
+	changed := newTestList()[0]
+	changed.ReadSet.Remove(addr, NONCE)
+	changed.AddReadSet(addr, slot)
+	moved := newTestList()[0]
+	moved.ReadSet.Remove(addr, NONCE)
+	moved.AddWriteSet(addr, NONCE)
+	for _, other := range []*RWSet{extra, changed, moved} {
+		if base.Equal(*other) || other.Equal(*base) {
+			t.Fatalf("%s should differ from %s", other.ToJsonStruct().ToString(), base.ToJsonStruct().ToString())
+		}
+	}
+}
+
+func TestJsonPseudoKeys(t *testing.T) {
+	data := `{"readSet":{"0x0000000000000000000000000000000000000001":["alive","balance","code","codeHash","nonce"]},"writeSet":{}}`
+	var set RWSet
+	if err := json.Unmarshal([]byte(data), &set); err != nil {
+		t.Fatal(err)
+	}
+	for _, hash := range []string{"balance", "nonce", "code", "codeHash", "alive"} {
+		found := false
+		for _, state := range set.ReadSet {
+			if _, ok := state[encodeHash(hash)]; ok {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("pseudo key %s not decoded", hash)
+		}
+	}
+	if set.ToJsonStruct().ToString() != data {
+		t.Fatalf("unexpected re-encoding: %s", set.ToJsonStruct().ToString())
+	}
+}
+
+func TestDeltaConflict(t *testing.T) {
+	coinbase := common.HexToAddress("0xc0")
+
+	delta1 := NewRWSet()
+	delta1.AddDeltaSet(coinbase, BALANCE)
+	delta2 := NewRWSet()
+	delta2.AddDeltaSet(coinbase, BALANCE)
+	reader := NewRWSet()
+	reader.AddReadSet(coinbase, BALANCE)
+	writer := NewRWSet()
+	writer.AddWriteSet(coinbase, BALANCE)
+
+	if delta1.HasConflict(*delta2) {
+		t.Fatal("additive writes should not conflict")
+	}
+	if !delta1.HasConflict(*reader) || !reader.HasConflict(*delta1) {
+		t.Fatal("additive write should conflict with read")
+	}
+	if !delta1.HasConflict(*writer) || !writer.HasConflict(*delta1) {
+		t.Fatal("additive write should conflict with overwrite")
+	}
+
+	// 普通写覆盖加法写
+	delta1.AddWriteSet(coinbase, BALANCE)
+	delta1.AddDeltaSet(coinbase, BALANCE)
+	if delta1.DeltaSet.Contains(coinbase, BALANCE) || !delta1.HasConflict(*delta2) {
+		t.Fatal("overwrite should take precedence over additive write")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"erigonInteract/utils"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// 导出一个区块的冲突图，供Graphviz或Gephi查看:
+//
+//	go run ./cmd/dumpgraph -block 18999999 -format graphml -out 18999999.graphml
+func main() {
+	blockNum := flag.Uint64("block", 18999999, "block number")
+	format := flag.String("format", "dot", "dot, graphml or json")
+	directed := flag.Bool("directed", false, "dump the directed graph instead of the undirected one")
+	reduce := flag.Bool("reduce", false, "apply transitive reduction to the directed graph")
+	out := flag.String("out", "", "output file, stdout if empty")
+	flag.Parse()
+
+	if err := run(*blockNum, *format, *directed, *reduce, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run 中的defer都会在main退出前执行，输出文件关闭失败也作为错误返回
+func run(blockNum uint64, format string, directed, reduce bool, out string) (err error) {
+	ctx, dbTx, blockReader, _ := utils.PrepareEnv()
+
+	w := os.Stdout
+	if out != "" {
+		file, createErr := os.Create(out)
+		if createErr != nil {
+			return createErr
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = file
+	}
+	return utils.DumpGraph(blockReader, ctx, dbTx, blockNum, format, directed, reduce, w)
+}
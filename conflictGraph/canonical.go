@@ -0,0 +1,92 @@
+package conflictgraph
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+)
+
+func sortUints(ids []uint) {
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+}
+
+func sortedVertexIds(vertices map[uint]*Vertex) []uint {
+	ids := make([]uint, 0, len(vertices))
+	for id := range vertices {
+		ids = append(ids, id)
+	}
+	sortUints(ids)
+	return ids
+}
+
+func sortedNeighborIds(neighbors map[uint]EdgeType) []uint {
+	ids := make([]uint, 0, len(neighbors))
+	for id := range neighbors {
+		ids = append(ids, id)
+	}
+	sortUints(ids)
+	return ids
+}
+
+// 规范格式按TxId排序，每行一个顶点或一条边:
+//
+//	v <TxId> <Cost>
+//	e <source> <destination> <EdgeType>
+//
+// Degree会被GetTopo等修改，不属于图本身，不写入
+func writeCanonical(w io.Writer, kind string, vertices map[uint]*Vertex, adjacency map[uint]map[uint]EdgeType, undirected bool) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, kind)
+	ids := sortedVertexIds(vertices)
+	for _, id := range ids {
+		fmt.Fprintln(bw, "v", id, vertices[id].Cost)
+	}
+	for _, id := range ids {
+		for _, neighborId := range sortedNeighborIds(adjacency[id]) {
+			// 无向边只写一次
+			if undirected && neighborId < id {
+				continue
+			}
+			fmt.Fprintln(bw, "e", id, neighborId, uint8(adjacency[id][neighborId]))
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteCanonical 写出与map遍历顺序无关的规范序列化，相同的图在任何机器上得到相同的字节
+func (g *UndirectedGraph) WriteCanonical(w io.Writer) error {
+	return writeCanonical(w, "undirected", g.Vertices, g.AdjacencyMap, true)
+}
+
+func (g *DirectedGraph) WriteCanonical(w io.Writer) error {
+	return writeCanonical(w, "directed", g.Vertices, g.AdjacencyMap, false)
+}
+
+// Checksum 规范序列化的sha256
+func (g *UndirectedGraph) Checksum() [32]byte {
+	var buf bytes.Buffer
+	g.WriteCanonical(&buf)
+	return sha256.Sum256(buf.Bytes())
+}
+
+func (g *DirectedGraph) Checksum() [32]byte {
+	var buf bytes.Buffer
+	g.WriteCanonical(&buf)
+	return sha256.Sum256(buf.Bytes())
+}
+
+// GroupsChecksum 调度分组的sha256，组的顺序有意义，组内先排序
+func GroupsChecksum(groups [][]uint) [32]byte {
+	var buf bytes.Buffer
+	for _, group := range groups {
+		sorted := append([]uint(nil), group...)
+		sortUints(sorted)
+		fmt.Fprintln(&buf, sorted)
+	}
+	return sha256.Sum256(buf.Bytes())
+}
@@ -0,0 +1,61 @@
+package conflictgraph
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCanonicalIndependentOfInsertionOrder(t *testing.T) {
+	edges := [][2]uint{{0, 3}, {1, 3}, {2, 4}, {3, 5}}
+
+	g1 := NewUndirectedGraph()
+	d1 := NewDirectedGraph()
+	for id := uint(0); id < 6; id++ {
+		g1.AddVertexWithCost(id, uint64(id)*100)
+		d1.AddVertexWithCost(id, uint64(id)*100)
+	}
+	for _, e := range edges {
+		g1.AddTypedEdge(e[0], e[1], 1)
+		d1.AddTypedEdge(e[0], e[1], 1)
+	}
+
+	g2 := NewUndirectedGraph()
+	d2 := NewDirectedGraph()
+	for id := 5; id >= 0; id-- {
+		g2.AddVertexWithCost(uint(id), uint64(id)*100)
+		d2.AddVertexWithCost(uint(id), uint64(id)*100)
+	}
+	for i := len(edges) - 1; i >= 0; i-- {
+		// 无向边反向插入
+		g2.AddTypedEdge(edges[i][1], edges[i][0], 1)
+		d2.AddTypedEdge(edges[i][0], edges[i][1], 1)
+	}
+
+	var buf1, buf2 bytes.Buffer
+	g1.WriteCanonical(&buf1)
+	g2.WriteCanonical(&buf2)
+	if !bytes.Equal(buf1.Bytes(), buf2.Bytes()) {
+		t.Fatalf("undirected canonical differs:\n%s\n%s", buf1.String(), buf2.String())
+	}
+	if d1.Checksum() != d2.Checksum() {
+		t.Fatal("directed checksum differs")
+	}
+
+	expectedComponents := [][]uint{{0, 1, 3, 5}, {2, 4}}
+	for i := 0; i < 20; i++ {
+		if components := g2.GetConnectedComponents(); !reflect.DeepEqual(components, expectedComponents) {
+			t.Fatalf("unexpected components %v", components)
+		}
+	}
+
+	expectedLevels := [][]uint{{0, 1, 2}, {3, 4}, {5}}
+	for i := 0; i < 20; i++ {
+		if levels, err := d2.Copy().GetTopo(); err != nil || !reflect.DeepEqual(levels, expectedLevels) {
+			t.Fatalf("unexpected levels %v", levels)
+		}
+	}
+	if GroupsChecksum(expectedLevels) != GroupsChecksum([][]uint{{2, 1, 0}, {4, 3}, {5}}) {
+		t.Fatal("groups checksum depends on order inside a group")
+	}
+}
@@ -0,0 +1,127 @@
+package conflictgraph
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// 着色结果转成轮次，颜色号即轮次号，每轮内按TxId升序
+func colorClasses(colors map[uint]int, colorNum int) [][]uint {
+	rounds := make([][]uint, colorNum)
+	for _, id := range sortedIdsOf(colors) {
+		rounds[colors[id]] = append(rounds[colors[id]], id)
+	}
+	return rounds
+}
+
+func sortedIdsOf(colors map[uint]int) []uint {
+	ids := make([]uint, 0, len(colors))
+	for id := range colors {
+		ids = append(ids, id)
+	}
+	sortUints(ids)
+	return ids
+}
+
+// 与id相邻的点都没有用到的最小颜色
+func (g *UndirectedGraph) smallestFreeColor(id uint, colors map[uint]int) int {
+	used := make(map[int]bool, len(g.AdjacencyMap[id]))
+	for neighborId := range g.AdjacencyMap[id] {
+		if c, ok := colors[neighborId]; ok {
+			used[c] = true
+		}
+	}
+	c := 0
+	for used[c] {
+		c++
+	}
+	return c
+}
+
+// WelshPowell 按度从大到小(度相同时TxId小的在前)依次着最小可用的颜色，
+// 每种颜色是一个独立集，返回的轮次与SolveMISInTurn的形状相同；不修改图
+func (g *UndirectedGraph) WelshPowell() [][]uint {
+	ids := sortedVertexIds(g.Vertices)
+	sort.SliceStable(ids, func(i, j int) bool {
+		return len(g.AdjacencyMap[ids[i]]) > len(g.AdjacencyMap[ids[j]])
+	})
+	colors := make(map[uint]int, len(ids))
+	colorNum := 0
+	for _, id := range ids {
+		c := g.smallestFreeColor(id, colors)
+		colors[id] = c
+		colorNum = max(colorNum, c+1)
+	}
+	return colorClasses(colors, colorNum)
+}
+
+// DSatur 每次给饱和度(邻居已用的不同颜色数)最大的点着色，
+// 饱和度相同时取度大的，再相同时取TxId小的；不修改图
+func (g *UndirectedGraph) DSatur() [][]uint {
+	ids := sortedVertexIds(g.Vertices)
+	colors := make(map[uint]int, len(ids))
+	saturation := make(map[uint]map[int]bool, len(ids))
+	candidates := make(dsaturHeap, 0, len(ids))
+	for _, id := range ids {
+		saturation[id] = make(map[int]bool)
+		candidates = append(candidates, dsaturItem{id: id, degree: len(g.AdjacencyMap[id])})
+	}
+	heap.Init(&candidates)
+	colorNum := 0
+	for candidates.Len() > 0 {
+		// 饱和度变化时会重新入堆，已着色或饱和度过期的是旧记录
+		item := heap.Pop(&candidates).(dsaturItem)
+		if _, ok := colors[item.id]; ok || item.saturation != len(saturation[item.id]) {
+			continue
+		}
+		c := g.smallestFreeColor(item.id, colors)
+		colors[item.id] = c
+		colorNum = max(colorNum, c+1)
+		for neighborId := range g.AdjacencyMap[item.id] {
+			if _, ok := colors[neighborId]; ok || saturation[neighborId][c] {
+				continue
+			}
+			saturation[neighborId][c] = true
+			heap.Push(&candidates, dsaturItem{id: neighborId, saturation: len(saturation[neighborId]), degree: len(g.AdjacencyMap[neighborId])})
+		}
+	}
+	return colorClasses(colors, colorNum)
+}
+
+type dsaturItem struct {
+	id         uint
+	saturation int
+	degree     int
+}
+
+// 大根堆，堆顶是DSatur下一个要着色的点
+type dsaturHeap []dsaturItem
+
+func (h dsaturHeap) Len() int {
+	return len(h)
+}
+
+func (h dsaturHeap) Less(i, j int) bool {
+	if h[i].saturation != h[j].saturation {
+		return h[i].saturation > h[j].saturation
+	}
+	if h[i].degree != h[j].degree {
+		return h[i].degree > h[j].degree
+	}
+	return h[i].id < h[j].id
+}
+
+func (h dsaturHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *dsaturHeap) Push(x interface{}) {
+	*h = append(*h, x.(dsaturItem))
+}
+
+func (h *dsaturHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
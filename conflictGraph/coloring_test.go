@@ -0,0 +1,126 @@
+package conflictgraph
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestColoring(t *testing.T) {
+	// 奇数环需要3种颜色，偶数环2种
+	for _, n := range []uint{5, 6} {
+		g := NewUndirectedGraph()
+		for id := uint(0); id < n; id++ {
+			g.AddVertex(id)
+		}
+		for id := uint(0); id < n; id++ {
+			g.AddEdge(id, (id+1)%n)
+		}
+		expected := 2 + int(n%2)
+		for _, rounds := range [][][]uint{g.WelshPowell(), g.DSatur()} {
+			if err := g.ValidateRounds(rounds); err != nil {
+				t.Fatal(err)
+			}
+			if len(rounds) != expected {
+				t.Fatalf("cycle of %d colored with %d colors", n, len(rounds))
+			}
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 50; iter++ {
+		g := NewUndirectedGraph()
+		n := 1 + r.Intn(80)
+		for id := 0; id < n; id++ {
+			g.AddVertex(uint(id))
+		}
+		p := r.Float64() * 0.3
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if r.Float64() < p {
+					g.AddEdge(uint(i), uint(j))
+				}
+			}
+		}
+		edgeNum := g.EdgeNum()
+		wp, ds := g.WelshPowell(), g.DSatur()
+		if err := g.ValidateRounds(wp); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.ValidateRounds(ds); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ds, g.DSatur()) || !reflect.DeepEqual(wp, g.WelshPowell()) {
+			t.Fatal("coloring is not deterministic")
+		}
+		if g.EdgeNum() != edgeNum || len(g.Vertices) != n {
+			t.Fatal("coloring modified the graph")
+		}
+	}
+}
+
+func TestValidateRounds(t *testing.T) {
+	g := NewUndirectedGraph()
+	for id := uint(0); id < 3; id++ {
+		g.AddVertex(id)
+	}
+	g.AddEdge(0, 1)
+	if err := g.ValidateRounds([][]uint{{0, 2}, {1}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, rounds := range [][][]uint{
+		{{0, 1}, {2}},
+		{{0, 2}},
+		{{0, 2}, {1, 2}},
+		{{0, 2}, {1, 3}},
+	} {
+		if g.ValidateRounds(rounds) == nil {
+			t.Fatalf("invalid rounds %v accepted", rounds)
+		}
+	}
+}
+
+// 逐个扫描未着色顶点的DSatur，堆实现的着色顺序必须和它一致
+func dsaturScan(g *UndirectedGraph) [][]uint {
+	ids := sortedVertexIds(g.Vertices)
+	colors := make(map[uint]int, len(ids))
+	saturation := make(map[uint]map[int]bool, len(ids))
+	for _, id := range ids {
+		saturation[id] = make(map[int]bool)
+	}
+	colorNum := 0
+	for range ids {
+		var next uint
+		found := false
+		for _, id := range ids {
+			if _, ok := colors[id]; ok {
+				continue
+			}
+			if !found || len(saturation[id]) > len(saturation[next]) ||
+				(len(saturation[id]) == len(saturation[next]) && len(g.AdjacencyMap[id]) > len(g.AdjacencyMap[next])) {
+				next, found = id, true
+			}
+		}
+		c := g.smallestFreeColor(next, colors)
+		colors[next] = c
+		colorNum = max(colorNum, c+1)
+		for neighborId := range g.AdjacencyMap[next] {
+			saturation[neighborId][c] = true
+		}
+	}
+	return colorClasses(colors, colorNum)
+}
+
+func TestDSaturHeap(t *testing.T) {
+	for _, named := range SyntheticGraphs(300) {
+		if !reflect.DeepEqual(named.Graph.DSatur(), dsaturScan(named.Graph)) {
+			t.Fatalf("%s: heap DSatur differs from the scan", named.Name)
+		}
+	}
+	for seed := int64(0); seed < 20; seed++ {
+		g := ErdosRenyi(100, 0.05, seed)
+		if !reflect.DeepEqual(g.DSatur(), dsaturScan(g)) {
+			t.Fatalf("seed %d: heap DSatur differs from the scan", seed)
+		}
+	}
+}
@@ -1,19 +1,42 @@
 package conflictgraph
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // UndirectedGraph 表示无向图
 type DirectedGraph struct {
 	Vertices     map[uint]*Vertex           `json:"vertices"`     // 顶点集合
-	AdjacencyMap map[uint]map[uint]struct{} `json:"adjacencyMap"` // 邻接边表
+	AdjacencyMap map[uint]map[uint]EdgeType `json:"adjacencyMap"` // 邻接边表, 值为边的冲突类型
 }
 
 func NewDirectedGraph() *DirectedGraph {
 	return &DirectedGraph{
 		Vertices:     make(map[uint]*Vertex),
-		AdjacencyMap: make(map[uint]map[uint]struct{}),
+		AdjacencyMap: make(map[uint]map[uint]EdgeType),
+	}
+}
+
+func (g *DirectedGraph) Copy() *DirectedGraph {
+	NewG := NewDirectedGraph()
+	for id, v := range g.Vertices {
+		NewG.AddVertexWithCost(id, v.Cost)
+	}
+	for id, neighbors := range g.AdjacencyMap {
+		for neighborId, t := range neighbors {
+			NewG.AddTypedEdge(id, neighborId, t)
+		}
 	}
+	return NewG
 }
 
 func (g *DirectedGraph) AddVertex(id uint) {
+	g.AddVertexWithCost(id, 0)
+}
+
+func (g *DirectedGraph) AddVertexWithCost(id uint, cost uint64) {
 	_, exist := g.Vertices[id]
 	if exist {
 		return
@@ -23,19 +46,46 @@ func (g *DirectedGraph) AddVertex(id uint) {
 		// TxHash: tx,
 		// IsDeleted: false,
 		Degree: 0,
+		Cost:   cost,
 	}
 	g.Vertices[id] = v
-	g.AdjacencyMap[id] = make(map[uint]struct{})
+	g.AdjacencyMap[id] = make(map[uint]EdgeType)
 }
 
 func (g *DirectedGraph) AddEdge(source, destination uint) {
+	g.AddTypedEdge(source, destination, 0)
+}
+
+// AddTypedEdge 添加一条带冲突类型的边，边已存在时合并冲突类型
+func (g *DirectedGraph) AddTypedEdge(source, destination uint, t EdgeType) {
 	if g.HasEdge(source, destination) {
+		g.AdjacencyMap[source][destination] |= t
 		return
 	}
-	g.AdjacencyMap[source][destination] = struct{}{}
+	g.AdjacencyMap[source][destination] = t
 	g.Vertices[destination].Degree++
 }
 
+func (g *DirectedGraph) EdgeNum() int {
+	num := 0
+	for _, neighbors := range g.AdjacencyMap {
+		num += len(neighbors)
+	}
+	return num
+}
+
+func (g *DirectedGraph) GetEdgeType(source, destination uint) EdgeType {
+	return g.AdjacencyMap[source][destination]
+}
+
+func (g *DirectedGraph) SumCost(group []uint) uint64 {
+	return sumCost(g.Vertices, group)
+}
+
+func (g *DirectedGraph) MaxCost(group []uint) uint64 {
+	return maxCost(g.Vertices, group)
+}
+
 func (g *DirectedGraph) HasEdge(source, destination uint) bool {
 	_, ok := g.Vertices[source]
 	if !ok {
@@ -51,15 +101,34 @@ func (g *DirectedGraph) HasEdge(source, destination uint) bool {
 	return ok
 }
 
-func (g *DirectedGraph) GetTopo() [][]uint {
+// CycleError 有向图中存在环，Cycle为环上的顶点(按边的方向，从id最小的顶点开始)
+type CycleError struct {
+	Cycle []uint
+}
+
+func (e *CycleError) Error() string {
+	ids := make([]string, 0, len(e.Cycle)+1)
+	for _, id := range e.Cycle {
+		ids = append(ids, fmt.Sprint(id))
+	}
+	if len(e.Cycle) > 0 {
+		ids = append(ids, fmt.Sprint(e.Cycle[0]))
+	}
+	return "cycle detected: " + strings.Join(ids, " -> ")
+}
+
+// GetTopo 按入度为0逐层剥离，每层内按TxId升序；
+// 有顶点没有被剥离时说明图中有环，返回已经得到的分层和*CycleError
+func (g *DirectedGraph) GetTopo() ([][]uint, error) {
 	ans := make([][]uint, 0)
 	degreeZero := make([]uint, 0)
-	for id, v := range g.Vertices {
-		if v.Degree == 0 {
+	for _, id := range sortedVertexIds(g.Vertices) {
+		if g.Vertices[id].Degree == 0 {
 			degreeZero = append(degreeZero, id)
 		}
 	}
 	ans = append(ans, degreeZero)
+	emitted := len(degreeZero)
 	for {
 		newDegreeZero := make([]uint, 0)
 		for _, vid := range degreeZero {
@@ -70,12 +139,241 @@ func (g *DirectedGraph) GetTopo() [][]uint {
 				}
 			}
 		}
+		sortUints(newDegreeZero)
 		degreeZero = newDegreeZero
 		if len(degreeZero) == 0 {
 			break
 		} else {
 			ans = append(ans, degreeZero)
+			emitted += len(degreeZero)
+		}
+	}
+	if emitted < len(g.Vertices) {
+		return ans, &CycleError{Cycle: g.findCycle()}
+	}
+	return ans, nil
+}
+
+// findCycle 在GetTopo剥离不掉的顶点中找一个环。这些顶点的入度都大于0，
+// 也就是都有一个同样没被剥离的前驱，沿前驱一直往回走必然会回到走过的顶点
+func (g *DirectedGraph) findCycle() []uint {
+	predecessors := make(map[uint][]uint)
+	for _, id := range sortedVertexIds(g.Vertices) {
+		if g.Vertices[id].Degree == 0 {
+			continue
+		}
+		for _, neighborId := range sortedNeighborIds(g.AdjacencyMap[id]) {
+			if g.Vertices[neighborId].Degree > 0 {
+				predecessors[neighborId] = append(predecessors[neighborId], id)
+			}
+		}
+	}
+	var start uint
+	found := false
+	for _, id := range sortedVertexIds(g.Vertices) {
+		if g.Vertices[id].Degree > 0 {
+			start, found = id, true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	pos := make(map[uint]int)
+	walk := make([]uint, 0)
+	for id := start; ; id = predecessors[id][0] {
+		if i, ok := pos[id]; ok {
+			walk = walk[i:]
+			break
+		}
+		pos[id] = len(walk)
+		walk = append(walk, id)
+	}
+	// 往回走得到的是反向的环，翻转后从id最小的顶点开始
+	for i, j := 0, len(walk)-1; i < j; i, j = i+1, j-1 {
+		walk[i], walk[j] = walk[j], walk[i]
+	}
+	minIdx := 0
+	for i, id := range walk {
+		if id < walk[minIdx] {
+			minIdx = i
+		}
+	}
+	return append(walk[minIdx:], walk[:minIdx]...)
+}
+
+// ValidateGroups 校验调度分组恰好覆盖了0到n-1的每笔交易一次，没有遗漏也没有重复
+func ValidateGroups(groups [][]uint, n int) error {
+	seen := make([]bool, n)
+	for _, group := range groups {
+		for _, id := range group {
+			if id >= uint(n) {
+				return fmt.Errorf("tx %d out of range, block has %d txs", id, n)
+			}
+			if seen[id] {
+				return fmt.Errorf("tx %d emitted more than once", id)
+			}
+			seen[id] = true
+		}
+	}
+	missing := make([]uint, 0)
+	for id, ok := range seen {
+		if !ok {
+			missing = append(missing, uint(id))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%d txs never emitted: %v", len(missing), missing)
+	}
+	return nil
+}
+
+// ValidateTopo 校验分层覆盖了图中每个顶点恰好一次，并且每条边的起点都在终点之前的层
+func (g *DirectedGraph) ValidateTopo(groups [][]uint) error {
+	level := make(map[uint]int, len(g.Vertices))
+	for i, group := range groups {
+		for _, id := range group {
+			if _, ok := g.Vertices[id]; !ok {
+				return fmt.Errorf("vertex %d not in graph", id)
+			}
+			if _, ok := level[id]; ok {
+				return fmt.Errorf("vertex %d emitted more than once", id)
+			}
+			level[id] = i
+		}
+	}
+	for _, id := range sortedVertexIds(g.Vertices) {
+		if _, ok := level[id]; !ok {
+			return fmt.Errorf("vertex %d never emitted", id)
+		}
+		for _, neighborId := range sortedNeighborIds(g.AdjacencyMap[id]) {
+			if level[id] >= level[neighborId] {
+				return fmt.Errorf("edge %d -> %d violated: level %d >= %d", id, neighborId, level[id], level[neighborId])
+			}
+		}
+	}
+	return nil
+}
+
+// CriticalPath 以顶点代价为权的最长路径，返回路径长度和路径上的顶点(按执行顺序)，
+// 即无限并行时DAG执行时间的下界；不修改Degree，可以和GetTopo一起使用
+func (g *DirectedGraph) CriticalPath() (uint64, []uint) {
+	inDegree := make(map[uint]uint, len(g.Vertices))
+	for _, neighbors := range g.AdjacencyMap {
+		for neighborId := range neighbors {
+			inDegree[neighborId]++
+		}
+	}
+	queue := make([]uint, 0)
+	for id := range g.Vertices {
+		if inDegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		return queue[i] < queue[j]
+	})
+
+	// dist[v] 为以v结尾的最长路径长度，prev记录路径上v的前驱
+	dist := make(map[uint]uint64, len(g.Vertices))
+	prev := make(map[uint]uint, len(g.Vertices))
+	var length uint64
+	var end uint
+	found := false
+	for len(queue) > 0 {
+		vid := queue[0]
+		queue = queue[1:]
+		dist[vid] += g.Vertices[vid].Cost
+		if !found || dist[vid] > length || (dist[vid] == length && vid < end) {
+			length, end, found = dist[vid], vid, true
+		}
+		for neighborId := range g.AdjacencyMap[vid] {
+			// 代价相同时取id较小的前驱, 保证结果确定
+			if _, ok := prev[neighborId]; !ok || dist[vid] > dist[neighborId] || (dist[vid] == dist[neighborId] && vid < prev[neighborId]) {
+				dist[neighborId] = dist[vid]
+				prev[neighborId] = vid
+			}
+			inDegree[neighborId]--
+			if inDegree[neighborId] == 0 {
+				queue = append(queue, neighborId)
+			}
+		}
+	}
+	if !found {
+		return 0, nil
+	}
+
+	path := []uint{end}
+	for {
+		p, ok := prev[path[len(path)-1]]
+		if !ok {
+			break
+		}
+		path = append(path, p)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return length, path
+}
+
+// TransitiveReduction 原地删除能由更长路径推出的边，得到最小的依赖DAG，返回删除的边数；
+// 可达关系和GetTopo的分层不变，Degree随之减小。被删除的边上的冲突类型不再保留。
+// 图中有环时传递约简不唯一，不做修改并返回0
+func (g *DirectedGraph) TransitiveReduction() int {
+	inDegree := make(map[uint]uint, len(g.Vertices))
+	for _, neighbors := range g.AdjacencyMap {
+		for neighborId := range neighbors {
+			inDegree[neighborId]++
+		}
+	}
+	order := make([]uint, 0, len(g.Vertices))
+	for _, id := range sortedVertexIds(g.Vertices) {
+		if inDegree[id] == 0 {
+			order = append(order, id)
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for neighborId := range g.AdjacencyMap[order[i]] {
+			inDegree[neighborId]--
+			if inDegree[neighborId] == 0 {
+				order = append(order, neighborId)
+			}
+		}
+	}
+	if len(order) < len(g.Vertices) {
+		return 0
+	}
+
+	// reach[i] 为拓扑序第i个顶点能到达的顶点集合(按拓扑序下标的位图)
+	index := make(map[uint]int, len(order))
+	for i, id := range order {
+		index[id] = i
+	}
+	words := (len(order) + 63) / 64
+	reach := make([][]uint64, len(order))
+	removed := 0
+	for i := len(order) - 1; i >= 0; i-- {
+		reach[i] = make([]uint64, words)
+		successors := make([]int, 0, len(g.AdjacencyMap[order[i]]))
+		for neighborId := range g.AdjacencyMap[order[i]] {
+			successors = append(successors, index[neighborId])
+		}
+		sort.Ints(successors)
+		// 按拓扑序从前往后处理直接后继，已经能从更早的后继到达的就是冗余边
+		for _, j := range successors {
+			if reach[i][j/64]&(1<<(j%64)) != 0 {
+				delete(g.AdjacencyMap[order[i]], order[j])
+				g.Vertices[order[j]].Degree--
+				removed++
+				continue
+			}
+			reach[i][j/64] |= 1 << (j % 64)
+			for w := range reach[j] {
+				reach[i][w] |= reach[j][w]
+			}
 		}
 	}
-	return ans
+	return removed
 }
@@ -0,0 +1,133 @@
+package conflictgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCriticalPath(t *testing.T) {
+	// 0 -> 2 -> 3, 1 -> 3, 4 独立
+	g := NewDirectedGraph()
+	costs := []uint64{10, 50, 30, 5, 40}
+	for id, cost := range costs {
+		g.AddVertexWithCost(uint(id), cost)
+	}
+	g.AddEdge(0, 2)
+	g.AddEdge(2, 3)
+	g.AddEdge(1, 3)
+
+	length, path := g.CriticalPath()
+	if length != 55 || !reflect.DeepEqual(path, []uint{1, 3}) {
+		t.Fatalf("unexpected critical path %d %v", length, path)
+	}
+
+	// 按层估算会把每层最重的交易相加: max(10,50,40) + 30 + 5
+	var levelCost uint64
+	levels, err := g.GetTopo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, level := range levels {
+		levelCost += g.MaxCost(level)
+	}
+	if levelCost != 85 {
+		t.Fatalf("unexpected level cost %d", levelCost)
+	}
+	// GetTopo会修改Degree, 不应影响CriticalPath
+	if l, _ := g.CriticalPath(); l != length {
+		t.Fatalf("critical path changed after GetTopo: %d", l)
+	}
+}
+
+func TestCriticalPathEmpty(t *testing.T) {
+	length, path := NewDirectedGraph().CriticalPath()
+	if length != 0 || path != nil {
+		t.Fatalf("unexpected critical path %d %v", length, path)
+	}
+}
+
+func TestTransitiveReduction(t *testing.T) {
+	// 0 -> 1 -> 2 -> 3 的链加上所有隐含边, 另有 0 -> 4 -> 3
+	g := NewDirectedGraph()
+	for id := uint(0); id < 5; id++ {
+		g.AddVertex(id)
+	}
+	for i := uint(0); i < 4; i++ {
+		for j := i + 1; j < 4; j++ {
+			g.AddTypedEdge(i, j, EdgeType(4))
+		}
+	}
+	g.AddEdge(0, 4)
+	g.AddEdge(4, 3)
+	levels, _ := g.Copy().GetTopo()
+
+	if removed := g.TransitiveReduction(); removed != 3 {
+		t.Fatalf("unexpected removed edge num %d", removed)
+	}
+	for _, e := range [][2]uint{{0, 1}, {1, 2}, {2, 3}, {0, 4}, {4, 3}} {
+		if !g.HasEdge(e[0], e[1]) {
+			t.Fatalf("edge %v should be kept", e)
+		}
+	}
+	if g.EdgeNum() != 5 || g.Vertices[3].Degree != 2 || g.GetEdgeType(1, 2) != EdgeType(4) {
+		t.Fatalf("unexpected graph after reduction %d %d", g.EdgeNum(), g.Vertices[3].Degree)
+	}
+	if reduced, _ := g.GetTopo(); !reflect.DeepEqual(reduced, levels) {
+		t.Fatal("reduction changed topo levels")
+	}
+	if g.TransitiveReduction() != 0 {
+		t.Fatal("reduction should be idempotent")
+	}
+}
+
+func TestGetTopoCycle(t *testing.T) {
+	// 0 -> 1 -> 2 -> 3 -> 1, 3 -> 4, 5 独立
+	g := NewDirectedGraph()
+	for id := uint(0); id < 6; id++ {
+		g.AddVertex(id)
+	}
+	g.AddEdge(0, 1)
+	g.AddEdge(1, 2)
+	g.AddEdge(2, 3)
+	g.AddEdge(3, 1)
+	g.AddEdge(3, 4)
+
+	levels, err := g.Copy().GetTopo()
+	cycleErr, ok := err.(*CycleError)
+	if !ok {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if !reflect.DeepEqual(cycleErr.Cycle, []uint{1, 2, 3}) || err.Error() != "cycle detected: 1 -> 2 -> 3 -> 1" {
+		t.Fatalf("unexpected cycle %v", err)
+	}
+	if !reflect.DeepEqual(levels, [][]uint{{0, 5}}) {
+		t.Fatalf("unexpected levels %v", levels)
+	}
+	if g.ValidateTopo(levels) == nil || ValidateGroups(levels, 6) == nil {
+		t.Fatal("incomplete levels should not validate")
+	}
+}
+
+func TestValidateTopo(t *testing.T) {
+	g := NewDirectedGraph()
+	for id := uint(0); id < 3; id++ {
+		g.AddVertex(id)
+	}
+	g.AddEdge(0, 2)
+	levels, err := g.Copy().GetTopo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ValidateTopo(levels); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateGroups(levels, 3); err != nil {
+		t.Fatal(err)
+	}
+	if g.ValidateTopo([][]uint{{0, 1, 2}}) == nil {
+		t.Fatal("edge inside a level should not validate")
+	}
+	if ValidateGroups([][]uint{{0, 1}, {1, 2}}, 3) == nil {
+		t.Fatal("duplicate tx should not validate")
+	}
+}
@@ -0,0 +1,170 @@
+package conflictgraph
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// String 与accesslist.ConflictType的输出一致，类型未知时为空串
+func (t EdgeType) String() string {
+	names := make([]string, 0, 3)
+	if t&1 != 0 {
+		names = append(names, "RAW")
+	}
+	if t&2 != 0 {
+		names = append(names, "WAR")
+	}
+	if t&4 != 0 {
+		names = append(names, "WAW")
+	}
+	return strings.Join(names, "|")
+}
+
+// VertexLabel 导出时顶点的附加信息，一般是交易哈希，为nil时不输出
+type VertexLabel func(id uint) string
+
+type exportVertex struct {
+	TxId   uint   `json:"txId"`
+	TxHash string `json:"txHash,omitempty"`
+	Cost   uint64 `json:"cost"`
+}
+
+type exportEdge struct {
+	Source uint   `json:"source"`
+	Target uint   `json:"target"`
+	Type   string `json:"type,omitempty"` // 冲突类型未知时为空
+}
+
+type exportGraph struct {
+	Directed bool           `json:"directed"`
+	Vertices []exportVertex `json:"vertices"`
+	Edges    []exportEdge   `json:"edges"`
+}
+
+// 按TxId排序的顶点和边，无向边只出现一次
+func newExportGraph(vertices map[uint]*Vertex, adjacency map[uint]map[uint]EdgeType, directed bool, label VertexLabel) exportGraph {
+	eg := exportGraph{
+		Directed: directed,
+		Vertices: make([]exportVertex, 0, len(vertices)),
+		Edges:    make([]exportEdge, 0),
+	}
+	ids := sortedVertexIds(vertices)
+	for _, id := range ids {
+		v := exportVertex{TxId: id, Cost: vertices[id].Cost}
+		if label != nil {
+			v.TxHash = label(id)
+		}
+		eg.Vertices = append(eg.Vertices, v)
+	}
+	for _, id := range ids {
+		for _, neighborId := range sortedNeighborIds(adjacency[id]) {
+			if !directed && neighborId < id {
+				continue
+			}
+			eg.Edges = append(eg.Edges, exportEdge{Source: id, Target: neighborId, Type: adjacency[id][neighborId].String()})
+		}
+	}
+	return eg
+}
+
+func (eg exportGraph) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(eg)
+}
+
+// DOT格式，可以直接用Graphviz渲染
+func (eg exportGraph) writeDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	kind, arrow := "graph", "--"
+	if eg.Directed {
+		kind, arrow = "digraph", "->"
+	}
+	fmt.Fprintf(bw, "%s conflict {\n", kind)
+	for _, v := range eg.Vertices {
+		label := fmt.Sprintf("%d", v.TxId)
+		if v.TxHash != "" {
+			label += "\\n" + v.TxHash
+		}
+		label += fmt.Sprintf("\\ngas=%d", v.Cost)
+		fmt.Fprintf(bw, "  %d [label=\"%s\"];\n", v.TxId, label)
+	}
+	for _, e := range eg.Edges {
+		if e.Type != "" {
+			fmt.Fprintf(bw, "  %d %s %d [label=\"%s\"];\n", e.Source, arrow, e.Target, e.Type)
+		} else {
+			fmt.Fprintf(bw, "  %d %s %d;\n", e.Source, arrow, e.Target)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// GraphML格式，Gephi可以直接导入
+func (eg exportGraph) writeGraphML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	edgeDefault := "undirected"
+	if eg.Directed {
+		edgeDefault = "directed"
+	}
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(bw, `  <key id="txHash" for="node" attr.name="txHash" attr.type="string"/>`)
+	fmt.Fprintln(bw, `  <key id="gas" for="node" attr.name="gas" attr.type="long"/>`)
+	fmt.Fprintln(bw, `  <key id="type" for="edge" attr.name="type" attr.type="string"/>`)
+	fmt.Fprintf(bw, "  <graph id=\"conflict\" edgedefault=\"%s\">\n", edgeDefault)
+	for _, v := range eg.Vertices {
+		fmt.Fprintf(bw, "    <node id=\"%d\">\n", v.TxId)
+		if v.TxHash != "" {
+			fmt.Fprintf(bw, "      <data key=\"txHash\">%s</data>\n", xmlEscape(v.TxHash))
+		}
+		fmt.Fprintf(bw, "      <data key=\"gas\">%d</data>\n", v.Cost)
+		fmt.Fprintln(bw, "    </node>")
+	}
+	for _, e := range eg.Edges {
+		if e.Type != "" {
+			fmt.Fprintf(bw, "    <edge source=\"%d\" target=\"%d\">\n", e.Source, e.Target)
+			fmt.Fprintf(bw, "      <data key=\"type\">%s</data>\n", e.Type)
+			fmt.Fprintln(bw, "    </edge>")
+		} else {
+			fmt.Fprintf(bw, "    <edge source=\"%d\" target=\"%d\"/>\n", e.Source, e.Target)
+		}
+	}
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+	return bw.Flush()
+}
+
+func (g *UndirectedGraph) WriteJSON(w io.Writer, label VertexLabel) error {
+	return newExportGraph(g.Vertices, g.AdjacencyMap, false, label).writeJSON(w)
+}
+
+func (g *UndirectedGraph) WriteDOT(w io.Writer, label VertexLabel) error {
+	return newExportGraph(g.Vertices, g.AdjacencyMap, false, label).writeDOT(w)
+}
+
+func (g *UndirectedGraph) WriteGraphML(w io.Writer, label VertexLabel) error {
+	return newExportGraph(g.Vertices, g.AdjacencyMap, false, label).writeGraphML(w)
+}
+
+func (g *DirectedGraph) WriteJSON(w io.Writer, label VertexLabel) error {
+	return newExportGraph(g.Vertices, g.AdjacencyMap, true, label).writeJSON(w)
+}
+
+func (g *DirectedGraph) WriteDOT(w io.Writer, label VertexLabel) error {
+	return newExportGraph(g.Vertices, g.AdjacencyMap, true, label).writeDOT(w)
+}
+
+func (g *DirectedGraph) WriteGraphML(w io.Writer, label VertexLabel) error {
+	return newExportGraph(g.Vertices, g.AdjacencyMap, true, label).writeGraphML(w)
+}
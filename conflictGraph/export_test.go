@@ -0,0 +1,65 @@
+package conflictgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExportUndirected(t *testing.T) {
+	g := NewUndirectedGraph()
+	g.AddVertexWithCost(0, 21000)
+	g.AddVertexWithCost(1, 50000)
+	g.AddVertexWithCost(2, 30000)
+	g.AddTypedEdge(0, 1, EdgeType(1))
+	g.AddTypedEdge(0, 1, EdgeType(4))
+	g.AddEdge(1, 2)
+	label := func(id uint) string { return fmt.Sprintf("0x%02x", id) }
+
+	var buf bytes.Buffer
+	if err := g.WriteJSON(&buf, label); err != nil {
+		t.Fatal(err)
+	}
+	var eg exportGraph
+	if err := json.Unmarshal(buf.Bytes(), &eg); err != nil {
+		t.Fatal(err)
+	}
+	// 无向边只导出一次
+	if eg.Directed || len(eg.Vertices) != 3 || len(eg.Edges) != 2 {
+		t.Fatalf("unexpected graph %+v", eg)
+	}
+	if eg.Edges[0] != (exportEdge{Source: 0, Target: 1, Type: "RAW|WAW"}) || eg.Edges[1].Type != "" {
+		t.Fatalf("unexpected edges %+v", eg.Edges)
+	}
+	if eg.Vertices[1] != (exportVertex{TxId: 1, TxHash: "0x01", Cost: 50000}) {
+		t.Fatalf("unexpected vertex %+v", eg.Vertices[1])
+	}
+
+	var dot1, dot2 bytes.Buffer
+	g.WriteDOT(&dot1, label)
+	g.WriteDOT(&dot2, label)
+	if dot1.String() != dot2.String() {
+		t.Fatal("dot output is not deterministic")
+	}
+	if !strings.Contains(dot1.String(), `0 -- 1 [label="RAW|WAW"];`) || !strings.Contains(dot1.String(), "1 -- 2;") {
+		t.Fatalf("unexpected dot output\n%s", dot1.String())
+	}
+}
+
+func TestExportDirectedGraphML(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddVertexWithCost(0, 1)
+	g.AddVertexWithCost(1, 2)
+	g.AddTypedEdge(0, 1, EdgeType(2))
+
+	var buf bytes.Buffer
+	if err := g.WriteGraphML(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `edgedefault="directed"`) || !strings.Contains(out, `<data key="type">WAR</data>`) || strings.Contains(out, "txHash\">") {
+		t.Fatalf("unexpected graphml output\n%s", out)
+	}
+}
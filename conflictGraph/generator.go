@@ -0,0 +1,138 @@
+package conflictgraph
+
+import "math/rand"
+
+// 合成冲突图，用于不依赖链上数据的测试和基准测试。
+// 顶点id为0到n-1，代价为随机的gas，相同的seed总是生成相同的图
+
+// 随机gas，下限为转账的21000
+func randomGas(r *rand.Rand) uint64 {
+	return 21000 + uint64(r.Intn(1000000))
+}
+
+func newRandomVertices(n int, r *rand.Rand) *UndirectedGraph {
+	g := NewUndirectedGraph()
+	for id := 0; id < n; id++ {
+		g.AddVertexWithCost(uint(id), randomGas(r))
+	}
+	return g
+}
+
+// ErdosRenyi G(n, p)随机图，每对顶点以概率p相连
+func ErdosRenyi(n int, p float64, seed int64) *UndirectedGraph {
+	r := rand.New(rand.NewSource(seed))
+	g := newRandomVertices(n, r)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if r.Float64() < p {
+				g.AddEdge(uint(i), uint(j))
+			}
+		}
+	}
+	return g
+}
+
+// PowerLaw Barabási–Albert优先连接，每个新顶点按度的比例连m个已有顶点，度服从幂律分布
+func PowerLaw(n, m int, seed int64) *UndirectedGraph {
+	r := rand.New(rand.NewSource(seed))
+	g := newRandomVertices(n, r)
+	// 每条边的两个端点都记一次，均匀抽取即按度的比例抽取
+	endpoints := make([]uint, 0, 2*n*m)
+	for id := 1; id < n; id++ {
+		targets := make(map[uint]bool, m)
+		for len(targets) < min(m, id) {
+			var target uint
+			if len(endpoints) == 0 {
+				target = uint(r.Intn(id))
+			} else {
+				target = endpoints[r.Intn(len(endpoints))]
+			}
+			targets[target] = true
+		}
+		for _, target := range sortedKeys(targets) {
+			g.AddEdge(uint(id), target)
+			endpoints = append(endpoints, uint(id), target)
+		}
+	}
+	return g
+}
+
+func sortedKeys(set map[uint]bool) []uint {
+	ids := make([]uint, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sortUints(ids)
+	return ids
+}
+
+// HotKeyStar 热点key: 前hubs个顶点是写热点key的交易，两两相连，
+// 其余每个顶点读这个key，与随机一个写者相连；例如一个DEX池子被一笔swap更新、被很多交易读取
+func HotKeyStar(n, hubs int, seed int64) *UndirectedGraph {
+	r := rand.New(rand.NewSource(seed))
+	g := newRandomVertices(n, r)
+	hubs = min(hubs, n)
+	for i := 0; i < hubs; i++ {
+		for j := i + 1; j < hubs; j++ {
+			g.AddEdge(uint(i), uint(j))
+		}
+	}
+	if hubs == 0 {
+		return g
+	}
+	for id := hubs; id < n; id++ {
+		g.AddEdge(uint(id), uint(r.Intn(hubs)))
+	}
+	return g
+}
+
+// CliqueChain cliques个大小为size的团，相邻的团之间用一条边串起来，
+// 相当于多个各自写同一个key的交易组，组与组之间有一笔交易同时访问两个key
+func CliqueChain(cliques, size int, seed int64) *UndirectedGraph {
+	r := rand.New(rand.NewSource(seed))
+	g := newRandomVertices(cliques*size, r)
+	for c := 0; c < cliques; c++ {
+		base := c * size
+		for i := 0; i < size; i++ {
+			for j := i + 1; j < size; j++ {
+				g.AddEdge(uint(base+i), uint(base+j))
+			}
+		}
+		if c > 0 {
+			g.AddEdge(uint(base-1), uint(base))
+		}
+	}
+	return g
+}
+
+// NamedGraph 带名字的合成图，基准测试按名字分组
+type NamedGraph struct {
+	Name  string
+	Graph *UndirectedGraph
+}
+
+// SyntheticGraphs 基准测试用的四种合成图，n=300时与主网区块的规模相近
+func SyntheticGraphs(n int) []NamedGraph {
+	return []NamedGraph{
+		{"ErdosRenyi", ErdosRenyi(n, 4/float64(n), 1)},
+		{"PowerLaw", PowerLaw(n, 2, 1)},
+		{"HotKeyStar", HotKeyStar(n, 3, 1)},
+		{"CliqueChain", CliqueChain(n/10, 10, 1)},
+	}
+}
+
+// ToDirected 按TxId定向，边从id小的指向id大的，与GenerateDiGraph一样总是无环的
+func (g *UndirectedGraph) ToDirected() *DirectedGraph {
+	d := NewDirectedGraph()
+	for id, v := range g.Vertices {
+		d.AddVertexWithCost(id, v.Cost)
+	}
+	for id, neighbors := range g.AdjacencyMap {
+		for neighborId, t := range neighbors {
+			if id < neighborId {
+				d.AddTypedEdge(id, neighborId, t)
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,117 @@
+package conflictgraph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGenerators(t *testing.T) {
+	for _, ng := range SyntheticGraphs(300) {
+		if len(ng.Graph.Vertices) != 300 {
+			t.Fatalf("%s: unexpected vertex num %d", ng.Name, len(ng.Graph.Vertices))
+		}
+		if ng.Graph.EdgeNum() == 0 {
+			t.Fatalf("%s: no edges", ng.Name)
+		}
+	}
+	// 相同的seed生成相同的图
+	for _, pair := range [][2]*UndirectedGraph{
+		{ErdosRenyi(100, 0.05, 7), ErdosRenyi(100, 0.05, 7)},
+		{PowerLaw(100, 3, 7), PowerLaw(100, 3, 7)},
+		{HotKeyStar(100, 2, 7), HotKeyStar(100, 2, 7)},
+		{CliqueChain(10, 5, 7), CliqueChain(10, 5, 7)},
+	} {
+		if pair[0].Checksum() != pair[1].Checksum() {
+			t.Fatal("same seed generated different graphs")
+		}
+	}
+	if ErdosRenyi(100, 0.05, 7).Checksum() == ErdosRenyi(100, 0.05, 8).Checksum() {
+		t.Fatal("different seeds generated the same graph")
+	}
+
+	chain := CliqueChain(4, 5, 1)
+	if chain.EdgeNum() != 4*10+3 || len(chain.GetConnectedComponents()) != 1 {
+		t.Fatalf("unexpected clique chain %d", chain.EdgeNum())
+	}
+	star := HotKeyStar(50, 3, 1)
+	if star.EdgeNum() != 3+47 {
+		t.Fatalf("unexpected hot key star %d", star.EdgeNum())
+	}
+}
+
+// 连通分量必须是顶点的一个划分，且分量之间没有边
+func checkComponents(b testing.TB, g *UndirectedGraph, components [][]uint) {
+	if err := ValidateGroups(components, len(g.Vertices)); err != nil {
+		b.Fatal(err)
+	}
+	componentOf := make(map[uint]int, len(g.Vertices))
+	for i, component := range components {
+		for _, id := range component {
+			componentOf[id] = i
+		}
+	}
+	for id, neighbors := range g.AdjacencyMap {
+		for neighborId := range neighbors {
+			if componentOf[id] != componentOf[neighborId] {
+				b.Fatalf("edge %d - %d crosses components", id, neighborId)
+			}
+		}
+	}
+}
+
+func BenchmarkGetConnectedComponents(b *testing.B) {
+	for _, n := range []int{300, 3000} {
+		for _, ng := range SyntheticGraphs(n) {
+			g := ng.Graph
+			b.Run(fmt.Sprintf("%s/%d", ng.Name, n), func(b *testing.B) {
+				checkComponents(b, g, g.GetConnectedComponents())
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					g.GetConnectedComponents()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkGetTopo(b *testing.B) {
+	for _, n := range []int{300, 3000} {
+		for _, ng := range SyntheticGraphs(n) {
+			d := ng.Graph.ToDirected()
+			b.Run(fmt.Sprintf("%s/%d", ng.Name, n), func(b *testing.B) {
+				levels, err := d.Copy().GetTopo()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := d.ValidateTopo(levels); err != nil {
+					b.Fatal(err)
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					// GetTopo会修改入度，复制不计入时间
+					b.StopTimer()
+					c := d.Copy()
+					b.StartTimer()
+					c.GetTopo()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkDSatur(b *testing.B) {
+	for _, n := range []int{300, 3000} {
+		for _, ng := range SyntheticGraphs(n) {
+			g := ng.Graph
+			b.Run(fmt.Sprintf("%s/%d", ng.Name, n), func(b *testing.B) {
+				if err := g.ValidateRounds(g.DSatur()); err != nil {
+					b.Fatal(err)
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					g.DSatur()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package conflictgraph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GraphStats 一个区块冲突图的结构统计，用来和cc.csv、dag.csv、mis.csv里的加速比做相关性分析
+type GraphStats struct {
+	VertexNum int
+	EdgeNum   int
+	Density   float64 // 边数 / (n*(n-1)/2)
+
+	DegreeHist    map[int]int // 度 -> 顶点数，度取无向图中的邻居数
+	ComponentHist map[int]int // 连通分量大小 -> 分量个数
+
+	ComponentNum      int
+	MaxComponentSize  int
+	TotalGas          uint64
+	MaxComponentGas   uint64  // gas之和最大的连通分量的gas，CC调度的下界
+	MaxComponentShare float64 // MaxComponentGas / TotalGas
+
+	TopoLevels      int
+	CriticalPathGas uint64
+	ReducedEdgeNum  int // 传递约简后有向图的边数，即真正的直接依赖数
+}
+
+// ComputeStats 统计无向图和有向图的结构信息，两个图应当由同一个区块生成；不会修改传入的图，
+// 有向图中有环时返回GetTopo的错误
+func ComputeStats(undiGraph *UndirectedGraph, diGraph *DirectedGraph) (GraphStats, error) {
+	stats := GraphStats{
+		VertexNum:     len(undiGraph.Vertices),
+		EdgeNum:       undiGraph.EdgeNum(),
+		DegreeHist:    make(map[int]int),
+		ComponentHist: make(map[int]int),
+	}
+	if stats.VertexNum > 1 {
+		stats.Density = float64(stats.EdgeNum) / (float64(stats.VertexNum) * float64(stats.VertexNum-1) / 2)
+	}
+	for id := range undiGraph.Vertices {
+		stats.DegreeHist[len(undiGraph.AdjacencyMap[id])]++
+	}
+
+	components := undiGraph.GetConnectedComponents()
+	stats.ComponentNum = len(components)
+	for _, component := range components {
+		stats.ComponentHist[len(component)]++
+		stats.MaxComponentSize = max(stats.MaxComponentSize, len(component))
+		gas := undiGraph.SumCost(component)
+		stats.TotalGas += gas
+		stats.MaxComponentGas = max(stats.MaxComponentGas, gas)
+	}
+	if stats.TotalGas > 0 {
+		stats.MaxComponentShare = float64(stats.MaxComponentGas) / float64(stats.TotalGas)
+	}
+
+	// GetTopo会修改入度
+	levels, err := diGraph.Copy().GetTopo()
+	if err != nil {
+		return stats, err
+	}
+	stats.TopoLevels = len(levels)
+	stats.CriticalPathGas, _ = diGraph.CriticalPath()
+	reduced := diGraph.Copy()
+	reduced.TransitiveReduction()
+	stats.ReducedEdgeNum = reduced.EdgeNum()
+	return stats, nil
+}
+
+// 直方图写成"key:count"并按key升序，用空格分隔，放在csv的一列里
+func formatHist(hist map[int]int) string {
+	keys := make([]int, 0, len(hist))
+	for k := range hist {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	items := make([]string, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, fmt.Sprintf("%d:%d", k, hist[k]))
+	}
+	return strings.Join(items, " ")
+}
+
+// StatsHeader 与Record对应的csv表头
+func StatsHeader() []string {
+	return []string{"vertexNum", "edgeNum", "density", "componentNum", "maxComponentSize", "totalGas", "maxComponentGas", "maxComponentShare", "topoLevels", "criticalPathGas", "reducedEdgeNum", "degreeHist", "componentHist"}
+}
+
+// Record 转成一行csv记录
+func (s GraphStats) Record() []string {
+	return []string{
+		fmt.Sprint(s.VertexNum),
+		fmt.Sprint(s.EdgeNum),
+		fmt.Sprintf("%.6f", s.Density),
+		fmt.Sprint(s.ComponentNum),
+		fmt.Sprint(s.MaxComponentSize),
+		fmt.Sprint(s.TotalGas),
+		fmt.Sprint(s.MaxComponentGas),
+		fmt.Sprintf("%.6f", s.MaxComponentShare),
+		fmt.Sprint(s.TopoLevels),
+		fmt.Sprint(s.CriticalPathGas),
+		fmt.Sprint(s.ReducedEdgeNum),
+		formatHist(s.DegreeHist),
+		formatHist(s.ComponentHist),
+	}
+}
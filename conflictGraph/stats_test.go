@@ -0,0 +1,48 @@
+package conflictgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputeStats(t *testing.T) {
+	// 0 - 1 - 2 一个分量, 3 单独
+	undi := NewUndirectedGraph()
+	di := NewDirectedGraph()
+	costs := []uint64{10, 20, 30, 40}
+	for id, cost := range costs {
+		undi.AddVertexWithCost(uint(id), cost)
+		di.AddVertexWithCost(uint(id), cost)
+	}
+	for _, e := range [][2]uint{{0, 1}, {1, 2}} {
+		undi.AddEdge(e[0], e[1])
+		di.AddEdge(e[0], e[1])
+	}
+
+	stats, err := ComputeStats(undi, di)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.VertexNum != 4 || stats.EdgeNum != 2 || stats.Density != 2.0/6 {
+		t.Fatalf("unexpected counts %+v", stats)
+	}
+	if !reflect.DeepEqual(stats.DegreeHist, map[int]int{0: 1, 1: 2, 2: 1}) {
+		t.Fatalf("unexpected degree hist %v", stats.DegreeHist)
+	}
+	if !reflect.DeepEqual(stats.ComponentHist, map[int]int{1: 1, 3: 1}) {
+		t.Fatalf("unexpected component hist %v", stats.ComponentHist)
+	}
+	if stats.MaxComponentGas != 60 || stats.MaxComponentShare != 0.6 {
+		t.Fatalf("unexpected component gas %d %f", stats.MaxComponentGas, stats.MaxComponentShare)
+	}
+	if stats.TopoLevels != 3 || stats.CriticalPathGas != 60 || stats.ReducedEdgeNum != 2 {
+		t.Fatalf("unexpected topo %d %d", stats.TopoLevels, stats.CriticalPathGas)
+	}
+	// 统计不修改传入的图
+	if di.Vertices[2].Degree != 1 {
+		t.Fatal("ComputeStats modified the directed graph")
+	}
+	if record := stats.Record(); len(record) != len(StatsHeader()) || record[11] != "0:1 1:2 2:1" {
+		t.Fatalf("unexpected record %v", record)
+	}
+}
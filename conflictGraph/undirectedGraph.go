@@ -1,37 +1,44 @@
 package conflictgraph
 
+import "fmt"
+
 // Vertex 表示图中的顶点
 type Vertex struct {
 	TxId uint `json:"txId"` // 顶点的 TxId
 	// TxHash common.Hash `json:"txHash"` // 顶点的 TxHash
 	// IsDeleted bool        `json:"isDeleted"`
-	Degree uint `json:"degree"` // 顶点的度, 在有向图里之代表入度
+	Degree uint   `json:"degree"` // 顶点的度, 在有向图里之代表入度
+	Cost   uint64 `json:"cost"`   // 顶点的代价, 一般取交易的gas
 }
 
+// EdgeType 边上的冲突类型位掩码，取值与accesslist.ConflictType相同(RAW=1, WAR=2, WAW=4)，
+// 方向以TxId较小的交易在前
+type EdgeType uint8
+
 // UndirectedGraph 表示无向图
 type UndirectedGraph struct {
 	Vertices map[uint]*Vertex `json:"vertices"` // 顶点集合
 	// AdjacencyMap map[uint][]uint  `json:"adjacencyMap"` // 邻接边表
-	AdjacencyMap map[uint]map[uint]struct{} `json:"adjacencyMap"` // 邻接边表
+	AdjacencyMap map[uint]map[uint]EdgeType `json:"adjacencyMap"` // 邻接边表, 值为边的冲突类型
 }
 
 // NewUndirectedGraph 创建一个新的无向图
 func NewUndirectedGraph() *UndirectedGraph {
 	return &UndirectedGraph{
 		Vertices:     make(map[uint]*Vertex),
-		AdjacencyMap: make(map[uint]map[uint]struct{}),
+		AdjacencyMap: make(map[uint]map[uint]EdgeType),
 	}
 }
 
 func (h *UndirectedGraph) Copy() *UndirectedGraph {
 	NewG := NewUndirectedGraph()
 
-	for id := range h.Vertices {
-		NewG.AddVertex(id)
+	for id, v := range h.Vertices {
+		NewG.AddVertexWithCost(id, v.Cost)
 	}
 	for id := range NewG.Vertices {
-		for neighborId := range h.AdjacencyMap[id] {
-			NewG.AddEdge(id, neighborId)
+		for neighborId, t := range h.AdjacencyMap[id] {
+			NewG.AddTypedEdge(id, neighborId, t)
 		}
 	}
 	return NewG
@@ -59,6 +66,10 @@ func (h *UndirectedGraph) Copy() *UndirectedGraph {
 
 // AddVertex 向图中添加一个顶点
 func (g *UndirectedGraph) AddVertex(id uint) {
+	g.AddVertexWithCost(id, 0)
+}
+
+func (g *UndirectedGraph) AddVertexWithCost(id uint, cost uint64) {
 	_, exist := g.Vertices[id]
 	if exist {
 		return
@@ -68,23 +79,71 @@ func (g *UndirectedGraph) AddVertex(id uint) {
 		// TxHash: tx,
 		//		IsDeleted: false,
 		Degree: 0,
+		Cost:   cost,
 	}
 	g.Vertices[id] = v
-	g.AdjacencyMap[id] = make(map[uint]struct{})
+	g.AdjacencyMap[id] = make(map[uint]EdgeType)
 }
 
 // AddEdge 向图中添加一条边
 func (g *UndirectedGraph) AddEdge(source, destination uint) {
+	g.AddTypedEdge(source, destination, 0)
+}
+
+// AddTypedEdge 添加一条带冲突类型的边，边已存在时合并冲突类型
+func (g *UndirectedGraph) AddTypedEdge(source, destination uint, t EdgeType) {
 	if g.HasEdge(source, destination) {
 		// 为了防止重复计算Degree
+		g.AdjacencyMap[source][destination] |= t
+		g.AdjacencyMap[destination][source] |= t
 		return
 	}
-	g.AdjacencyMap[source][destination] = struct{}{}
-	g.AdjacencyMap[destination][source] = struct{}{}
+	g.AdjacencyMap[source][destination] = t
+	g.AdjacencyMap[destination][source] = t
 	g.Vertices[source].Degree++
 	g.Vertices[destination].Degree++
 }
 
+// EdgeNum 边数，每条无向边在邻接表中出现两次
+func (g *UndirectedGraph) EdgeNum() int {
+	num := 0
+	for _, neighbors := range g.AdjacencyMap {
+		num += len(neighbors)
+	}
+	return num / 2
+}
+
+// GetEdgeType 返回边的冲突类型，边不存在时为0
+func (g *UndirectedGraph) GetEdgeType(tx1, tx2 uint) EdgeType {
+	return g.AdjacencyMap[tx1][tx2]
+}
+
+// SumCost 一组顶点的代价之和，即这组交易串行执行的代价
+func (g *UndirectedGraph) SumCost(group []uint) uint64 {
+	return sumCost(g.Vertices, group)
+}
+
+// MaxCost 一组顶点中的最大代价，即这组交易并行执行的代价
+func (g *UndirectedGraph) MaxCost(group []uint) uint64 {
+	return maxCost(g.Vertices, group)
+}
+
+func sumCost(vertices map[uint]*Vertex, group []uint) uint64 {
+	var sum uint64
+	for _, id := range group {
+		sum += vertices[id].Cost
+	}
+	return sum
+}
+
+func maxCost(vertices map[uint]*Vertex, group []uint) uint64 {
+	var res uint64
+	for _, id := range group {
+		res = max(res, vertices[id].Cost)
+	}
+	return res
+}
+
 func (g *UndirectedGraph) HasEdge(tx1, tx2 uint) bool {
 	_, ok := g.Vertices[tx1]
 	if !ok {
@@ -111,15 +170,62 @@ func (g *UndirectedGraph) RemoveVertex(tx uint) {
 	delete(g.Vertices, tx)
 }
 
+// ValidateRounds 校验每一轮都是图中的独立集，并且所有轮次覆盖了图中每个顶点恰好一次
+func (g *UndirectedGraph) ValidateRounds(rounds [][]uint) error {
+	roundOf := make(map[uint]int, len(g.Vertices))
+	for i, round := range rounds {
+		for _, id := range round {
+			if _, ok := g.Vertices[id]; !ok {
+				return fmt.Errorf("vertex %d not in graph", id)
+			}
+			if _, ok := roundOf[id]; ok {
+				return fmt.Errorf("vertex %d emitted more than once", id)
+			}
+			roundOf[id] = i
+		}
+	}
+	for _, id := range sortedVertexIds(g.Vertices) {
+		if _, ok := roundOf[id]; !ok {
+			return fmt.Errorf("vertex %d never emitted", id)
+		}
+		for _, neighborId := range sortedNeighborIds(g.AdjacencyMap[id]) {
+			if roundOf[id] == roundOf[neighborId] {
+				return fmt.Errorf("%d and %d in round %d are adjacent", id, neighborId, roundOf[id])
+			}
+		}
+	}
+	return nil
+}
+
+// Subgraph 由ids导出的子图，保留顶点代价和边的冲突类型
+func (g *UndirectedGraph) Subgraph(ids []uint) *UndirectedGraph {
+	sub := NewUndirectedGraph()
+	for _, id := range ids {
+		if v, ok := g.Vertices[id]; ok {
+			sub.AddVertexWithCost(id, v.Cost)
+		}
+	}
+	for id := range sub.Vertices {
+		for neighborId, t := range g.AdjacencyMap[id] {
+			if _, ok := sub.Vertices[neighborId]; ok {
+				sub.AddTypedEdge(id, neighborId, t)
+			}
+		}
+	}
+	return sub
+}
+
 // GetConnectedComponents 获取图中的连通分量（使用深度优先搜索）
+// 分量内按TxId升序，分量之间按最小的TxId升序，保证每次运行结果一致
 func (g *UndirectedGraph) GetConnectedComponents() [][]uint {
 	visited := make(map[uint]bool)
 	components := make([][]uint, 0)
 
-	for key := range g.Vertices {
+	for _, key := range sortedVertexIds(g.Vertices) {
 		if !visited[key] {
 			component := make([]uint, 0)
 			g.dfs(key, visited, &component)
+			sortUints(component)
 			components = append(components, component)
 		}
 	}
@@ -0,0 +1,88 @@
+package conflictgraph
+
+import "sort"
+
+// UnionFind 并查集，按大小合并并压缩路径，用来在线维护连通分量
+type UnionFind struct {
+	parent map[uint]uint
+	size   map[uint]int
+}
+
+func NewUnionFind() *UnionFind {
+	return &UnionFind{
+		parent: make(map[uint]uint),
+		size:   make(map[uint]int),
+	}
+}
+
+// Add 加入一个单独成组的顶点，已存在时什么都不做
+func (uf *UnionFind) Add(id uint) {
+	if _, ok := uf.parent[id]; ok {
+		return
+	}
+	uf.parent[id] = id
+	uf.size[id] = 1
+}
+
+// Find 返回id所在分量的代表元，用迭代避免长链上的深递归
+func (uf *UnionFind) Find(id uint) uint {
+	root := id
+	for uf.parent[root] != root {
+		root = uf.parent[root]
+	}
+	for id != root {
+		next := uf.parent[id]
+		uf.parent[id] = root
+		id = next
+	}
+	return root
+}
+
+// Union 合并两个顶点所在的分量，原本就在同一分量时返回false
+func (uf *UnionFind) Union(a, b uint) bool {
+	rootA, rootB := uf.Find(a), uf.Find(b)
+	if rootA == rootB {
+		return false
+	}
+	if uf.size[rootA] < uf.size[rootB] {
+		rootA, rootB = rootB, rootA
+	}
+	uf.parent[rootB] = rootA
+	uf.size[rootA] += uf.size[rootB]
+	delete(uf.size, rootB)
+	return true
+}
+
+// Size id所在分量的顶点数
+func (uf *UnionFind) Size(id uint) int {
+	return uf.size[uf.Find(id)]
+}
+
+func (uf *UnionFind) Len() int {
+	return len(uf.parent)
+}
+
+// Components 返回所有分量，分量内按id升序，分量之间按最小id升序
+func (uf *UnionFind) Components() [][]uint {
+	ids := make([]uint, 0, len(uf.parent))
+	for id := range uf.parent {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	index := make(map[uint]int, len(uf.size))
+	components := make([][]uint, 0, len(uf.size))
+	for _, id := range ids {
+		root := uf.Find(id)
+		i, ok := index[root]
+		if !ok {
+			i = len(components)
+			index[root] = i
+			components = append(components, make([]uint, 0, uf.size[root]))
+		}
+		components[i] = append(components[i], id)
+	}
+	return components
+}
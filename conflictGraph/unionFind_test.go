@@ -0,0 +1,42 @@
+package conflictgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnionFind(t *testing.T) {
+	uf := NewUnionFind()
+	for id := uint(0); id < 6; id++ {
+		uf.Add(id)
+	}
+	uf.Union(4, 1)
+	uf.Union(1, 3)
+	if uf.Union(3, 4) {
+		t.Fatal("3 and 4 are already in the same component")
+	}
+	uf.Union(5, 2)
+
+	expected := [][]uint{{0}, {1, 3, 4}, {2, 5}}
+	if components := uf.Components(); !reflect.DeepEqual(components, expected) {
+		t.Fatalf("unexpected components %v", components)
+	}
+	if uf.Size(4) != 3 || uf.Size(0) != 1 {
+		t.Fatalf("unexpected sizes %d %d", uf.Size(4), uf.Size(0))
+	}
+}
+
+func TestUnionFindLongChain(t *testing.T) {
+	// 长链不会像递归dfs那样爆栈
+	uf := NewUnionFind()
+	const n = 1 << 16
+	for id := uint(0); id < n; id++ {
+		uf.Add(id)
+		if id > 0 {
+			uf.Union(id-1, id)
+		}
+	}
+	if uf.Size(0) != n || len(uf.Components()) != 1 {
+		t.Fatal("chain should be one component")
+	}
+}
@@ -8,7 +8,10 @@ import (
 	interactState "erigonInteract/state"
 	"erigonInteract/tracer"
 	"erigonInteract/utils"
+	"flag"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -121,21 +124,17 @@ func apexPlusExec(blockReader *freezeblocks.BlockReader, ctx context.Context, db
 		abortTids = append(abortTids, GriaProcessor[i].GetAbortTids()...)
 	}
 
-	// 获取abort的交易和predictRwset
+	// 获取abort的交易和predictRwset，边收集边加入增量冲突图
 	abortTxs := make([]types.Transaction, 0)
 	abortPredictRwSets := make([]*accesslist.RWSet, 0)
+	incGraph := utils.NewIncrementalGraph()
 
 	for _, tid := range abortTids {
+		incGraph.AddTxWithCost(uint(len(abortTxs)), predictRwSetss[tid], txss[tid].GetGas())
 		abortTxs = append(abortTxs, txss[tid])
 		abortPredictRwSets = append(abortPredictRwSets, predictRwSetss[tid])
 	}
 
-	// 生成新的rwAccessedBy
-	rwAccessedBy := accesslist.NewRwAccessedBy()
-	for i, _ := range abortTxs {
-		rwAccessedBy.Add(abortPredictRwSets[i], uint(i))
-	}
-
 	// 使用CC并行执行剩余交易
 	// 准备线程池
 	var antsWG sync.WaitGroup
@@ -143,8 +142,8 @@ func apexPlusExec(blockReader *freezeblocks.BlockReader, ctx context.Context, db
 	defer antsPool.Release()
 	// 建图分组
 	graphStart := time.Now()
-	// 使用预取RWset构建图
-	vIdsGroups := utils.GenerateVertexIdGroups(abortTxs, rwAccessedBy)
+	// 连通分量直接取自增量图的并查集，与GenerateVertexIdGroups的结果相同
+	vIdsGroups := incGraph.VertexIdGroups()
 	graphTime := time.Since(graphStart)
 
 	groupstart := time.Now()
@@ -186,7 +185,44 @@ func apexPlusExec(blockReader *freezeblocks.BlockReader, ctx context.Context, db
 
 }
 
+// 分析报告默认不生成，需要时用-reports选择，如 go run . -reports accuracy
+var (
+	reports    = flag.String("reports", "", "comma separated reports to generate: accuracy, hotkey, accesslist, sparse, graphstats, color")
+	hotKeyTopN = flag.Int("hotkey-top", 20, "number of keys printed by the hotkey report")
+)
+
+// runReports 依次生成选中的报告，遇到错误立即返回
+func runReports(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64, names string, topN int) error {
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		var err error
+		switch name {
+		case "":
+			continue
+		case "accuracy":
+			err = utils.AccuracyTest(blockReader, ctx, dbTx, blockNum)
+		case "hotkey":
+			err = utils.HotKeyTest(blockReader, ctx, dbTx, blockNum, topN)
+		case "accesslist":
+			err = utils.AccessListTest(blockReader, ctx, dbTx, blockNum)
+		case "sparse":
+			err = utils.SparseGraphTest(blockReader, ctx, dbTx, blockNum)
+		case "graphstats":
+			err = utils.GraphStatsTest(blockReader, ctx, dbTx, blockNum)
+		case "color":
+			err = utils.ColorTest(blockReader, ctx, dbTx, blockNum)
+		default:
+			return fmt.Errorf("unknown report %q", name)
+		}
+		if err != nil {
+			return fmt.Errorf("%s report: %w", name, err)
+		}
+	}
+	return nil
+}
+
 func main() {
+	flag.Parse()
 
 	ctx, dbTx, blockReader, db := utils.PrepareEnv()
 
@@ -197,6 +233,11 @@ func main() {
 	utils.MISTest(blockReader, ctx, dbTx, 18999999-499)
 	utils.DAGTest(blockReader, ctx, dbTx, 18999999-499)
 
+	if err := runReports(blockReader, ctx, dbTx, 18999999-499, *reports, *hotKeyTopN); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	pe := schedule.NewPipeLineExecutor()
 	pe.PipeLineExec(blockReader, ctx, db, 18999999-499)
 
@@ -0,0 +1,146 @@
+package mis
+
+import (
+	conflictgraph "erigonInteract/conflictGraph"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 单个独立集的校验: 独立集加上其余每个点单独一轮，应当是合法的轮次
+func checkIndependent(tb testing.TB, g *conflictgraph.UndirectedGraph, set []uint) {
+	rounds := [][]uint{set}
+	inSet := make(map[uint]bool, len(set))
+	for _, id := range set {
+		inSet[id] = true
+	}
+	for id := range g.Vertices {
+		if !inSet[id] {
+			rounds = append(rounds, []uint{id})
+		}
+	}
+	if err := g.ValidateRounds(rounds); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func linearTimeSet(g *conflictgraph.UndirectedGraph) []uint {
+	solution := NewSolution(g)
+	solution.Solve()
+	res := make([]uint, 0, solution.IndependentSet.Cardinality())
+	for _, v := range solution.IndependentSet.ToSlice() {
+		res = append(res, v.(uint))
+	}
+	return res
+}
+
+// 逐轮求独立集，每一轮都必须是独立集，所有轮次必须是顶点的一个划分
+func checkInTurn(tb testing.TB, g *conflictgraph.UndirectedGraph, solve func(*conflictgraph.UndirectedGraph) []uint) int {
+	rest := g.Copy()
+	rounds := make([][]uint, 0)
+	for len(rest.Vertices) > 0 {
+		round := solve(rest.Copy())
+		if len(round) == 0 {
+			tb.Fatal("empty round")
+		}
+		for _, v := range round {
+			rest.RemoveVertex(v)
+		}
+		rounds = append(rounds, round)
+	}
+	if err := g.ValidateRounds(rounds); err != nil {
+		tb.Fatal(err)
+	}
+	return len(rounds)
+}
+
+func TestSyntheticSolvers(t *testing.T) {
+	for _, ng := range conflictgraph.SyntheticGraphs(300) {
+		checkInTurn(t, ng.Graph, linearTimeSet)
+		checkInTurn(t, ng.Graph, func(g *conflictgraph.UndirectedGraph) []uint {
+			solution := NewDeterministicSolution(g)
+			solution.Solve()
+			return solution.Result()
+		})
+		checkInTurn(t, ng.Graph, func(g *conflictgraph.UndirectedGraph) []uint {
+			return GreedyWeightedMIS(g, GasWeight)
+		})
+	}
+}
+
+func BenchmarkLinearTime(b *testing.B) {
+	for _, n := range []int{300, 3000} {
+		for _, ng := range conflictgraph.SyntheticGraphs(n) {
+			g := ng.Graph
+			b.Run(fmt.Sprintf("%s/%d", ng.Name, n), func(b *testing.B) {
+				set := linearTimeSet(g.Copy())
+				checkIndependent(b, g, set)
+				b.ReportMetric(float64(len(set)), "mis")
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					// Solve会修改图，复制不计入时间
+					b.StopTimer()
+					c := g.Copy()
+					b.StartTimer()
+					linearTimeSet(c)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkDeterministicLinearTime(b *testing.B) {
+	for _, n := range []int{300, 3000} {
+		for _, ng := range conflictgraph.SyntheticGraphs(n) {
+			g := ng.Graph
+			b.Run(fmt.Sprintf("%s/%d", ng.Name, n), func(b *testing.B) {
+				solution := NewDeterministicSolution(g.Copy())
+				solution.Solve()
+				checkIndependent(b, g, solution.Result())
+				b.ReportMetric(float64(len(solution.Result())), "mis")
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					c := g.Copy()
+					b.StartTimer()
+					NewDeterministicSolution(c).Solve()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkExactMIS(b *testing.B) {
+	// 精确解只用在小分量上
+	for _, ng := range conflictgraph.SyntheticGraphs(60) {
+		g := ng.Graph
+		b.Run(ng.Name, func(b *testing.B) {
+			set, _ := ExactMIS(g, time.Second)
+			checkIndependent(b, g, set)
+			b.ReportMetric(float64(len(set)), "mis")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ExactMIS(g, time.Second)
+			}
+		})
+	}
+}
+
+// 逐轮求解的总时间，对应mis.csv中的group列
+func BenchmarkLinearTimeInTurn(b *testing.B) {
+	for _, ng := range conflictgraph.SyntheticGraphs(300) {
+		g := ng.Graph
+		b.Run(ng.Name, func(b *testing.B) {
+			b.ReportMetric(float64(checkInTurn(b, g, linearTimeSet)), "rounds")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rest := g.Copy()
+				for len(rest.Vertices) > 0 {
+					for _, v := range linearTimeSet(rest.Copy()) {
+						rest.RemoveVertex(v)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package mis
+
+import (
+	conflictgraph "erigonInteract/conflictGraph"
+	"sort"
+)
+
+// orderedSet 按TxId升序存储的集合，Pick总是返回最小的id，代替底层为Map的set.Set
+type orderedSet struct {
+	ids []uint
+}
+
+func (s *orderedSet) search(id uint) int {
+	return sort.Search(len(s.ids), func(i int) bool {
+		return s.ids[i] >= id
+	})
+}
+
+func (s *orderedSet) Add(id uint) {
+	i := s.search(id)
+	if i < len(s.ids) && s.ids[i] == id {
+		return
+	}
+	s.ids = append(s.ids, 0)
+	copy(s.ids[i+1:], s.ids[i:])
+	s.ids[i] = id
+}
+
+func (s *orderedSet) Remove(id uint) {
+	i := s.search(id)
+	if i < len(s.ids) && s.ids[i] == id {
+		s.ids = append(s.ids[:i], s.ids[i+1:]...)
+	}
+}
+
+func (s *orderedSet) Contains(id uint) bool {
+	i := s.search(id)
+	return i < len(s.ids) && s.ids[i] == id
+}
+
+func (s *orderedSet) Len() int {
+	return len(s.ids)
+}
+
+// Pick 最小的id
+func (s *orderedSet) Pick() uint {
+	return s.ids[0]
+}
+
+func (s *orderedSet) ToSlice() []uint {
+	return append([]uint(nil), s.ids...)
+}
+
+// DeterministicLinearTime 与LinearTime的规约规则相同，但度数分桶按TxId有序，
+// 邻居也按TxId升序遍历，同一个图每次求出的独立集都一样
+type DeterministicLinearTime struct {
+	Graph *conflictgraph.UndirectedGraph
+
+	VerticesOne, VerticesTwo, VerticesGreaterThanThree, IndependentSet *orderedSet
+
+	Stack VertexStack
+
+	reducer *reducer
+}
+
+func NewDeterministicSolution(graph *conflictgraph.UndirectedGraph) *DeterministicLinearTime {
+	s := &DeterministicLinearTime{
+		Graph:                    graph,
+		VerticesOne:              &orderedSet{},
+		VerticesTwo:              &orderedSet{},
+		VerticesGreaterThanThree: &orderedSet{},
+		IndependentSet:           &orderedSet{},
+		Stack:                    make([]uint, 0),
+	}
+	s.reducer = newReducer(graph, s.VerticesOne, s.VerticesTwo, s.VerticesGreaterThanThree, s.IndependentSet, &s.Stack, true)
+	return s
+}
+
+func (s *DeterministicLinearTime) Solve() {
+	s.reducer.solve()
+}
+
+// Result 求得的独立集，按TxId升序
+func (s *DeterministicLinearTime) Result() []uint {
+	return s.IndependentSet.ToSlice()
+}
@@ -0,0 +1,119 @@
+package mis
+
+import (
+	conflictgraph "erigonInteract/conflictGraph"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func randomGraph(r *rand.Rand, n int, p float64) *conflictgraph.UndirectedGraph {
+	G := conflictgraph.NewUndirectedGraph()
+	for i := 0; i < n; i++ {
+		G.AddVertex(uint(i))
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if r.Float64() < p {
+				G.AddEdge(uint(i), uint(j))
+			}
+		}
+	}
+	return G
+}
+
+// 逐轮求独立集直到删光所有顶点，与SolveMISInTurn相同
+func solveInTurn(t *testing.T, graph *conflictgraph.UndirectedGraph) [][]uint {
+	rounds := make([][]uint, 0)
+	for len(graph.Vertices) > 0 {
+		solution := NewDeterministicSolution(graph.Copy())
+		solution.Solve()
+		round := solution.Result()
+		for i, u := range round {
+			for _, v := range round[i+1:] {
+				if graph.HasEdge(u, v) {
+					t.Fatalf("%d and %d in the same round are adjacent", u, v)
+				}
+			}
+		}
+		if len(round) == 0 {
+			t.Fatal("empty round")
+		}
+		for _, v := range round {
+			graph.RemoveVertex(v)
+		}
+		rounds = append(rounds, round)
+	}
+	return rounds
+}
+
+func TestDeterministicSolve(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 50; iter++ {
+		graph := randomGraph(r, 5+r.Intn(60), r.Float64()*0.3)
+		expected := solveInTurn(t, graph.Copy())
+		for i := 0; i < 10; i++ {
+			if rounds := solveInTurn(t, graph.Copy()); !reflect.DeepEqual(rounds, expected) {
+				t.Fatalf("rounds differ between runs: %v vs %v", rounds, expected)
+			}
+		}
+	}
+}
+
+func TestDeterministicSolveGraph(t *testing.T) {
+	for _, graph := range []*conflictgraph.UndirectedGraph{NewGraph(), NewGraph2()} {
+		solution := NewDeterministicSolution(graph.Copy())
+		solution.Solve()
+		again := NewDeterministicSolution(graph.Copy())
+		again.Solve()
+		if !reflect.DeepEqual(solution.Result(), again.Result()) {
+			t.Fatalf("unexpected result %v vs %v", solution.Result(), again.Result())
+		}
+		if len(solution.Result()) < 2 {
+			t.Fatalf("independent set too small %v", solution.Result())
+		}
+	}
+}
+
+// 0-1-2是两端连着两个K4的奇数长路径，规约后path[0]的度仍是2，必须还在VerticesTwo中
+func oddPathGraph() *conflictgraph.UndirectedGraph {
+	G := conflictgraph.NewUndirectedGraph()
+	for i := 0; i < 11; i++ {
+		G.AddVertex(uint(i))
+	}
+	for _, e := range [][2]uint{{0, 1}, {1, 2}, {0, 3}, {2, 4}} {
+		G.AddEdge(e[0], e[1])
+	}
+	for _, clique := range [][]uint{{3, 5, 6, 9}, {4, 7, 8, 10}} {
+		for i, u := range clique {
+			for _, v := range clique[i+1:] {
+				G.AddEdge(u, v)
+			}
+		}
+	}
+	return G
+}
+
+func TestOddPathKeepsEndpoint(t *testing.T) {
+	det := NewDeterministicSolution(oddPathGraph())
+	det.reducer.degreeTwoPathReduction()
+	if !reflect.DeepEqual(det.VerticesTwo.ToSlice(), []uint{0}) || det.Graph.Vertices[0].Degree != 2 {
+		t.Fatalf("expected vertex 0 with degree 2 in VerticesTwo, got %v", det.VerticesTwo.ToSlice())
+	}
+
+	linear := NewSolution(oddPathGraph())
+	linear.reducer.degreeTwoPathReduction()
+	if linear.VerticesTwo.Cardinality() != 1 {
+		t.Fatalf("expected the kept endpoint in VerticesTwo, got %v", linear.VerticesTwo)
+	}
+	kept := linear.VerticesTwo.ToSlice()[0].(uint)
+	if linear.Graph.Vertices[kept].Degree != 2 {
+		t.Fatalf("vertex %d in VerticesTwo has degree %d", kept, linear.Graph.Vertices[kept].Degree)
+	}
+
+	for _, graph := range []*conflictgraph.UndirectedGraph{oddPathGraph(), NewGraph(), NewGraph2()} {
+		solution := NewDeterministicSolution(graph.Copy())
+		solution.Solve()
+		checkIndependent(t, graph, solution.Result())
+	}
+}
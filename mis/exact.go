@@ -0,0 +1,211 @@
+package mis
+
+import (
+	conflictgraph "erigonInteract/conflictGraph"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+// bitset 顶点集合，第i位表示按TxId排序后的第i个顶点
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) set(i int)      { b[i/64] |= 1 << (i % 64) }
+func (b bitset) clear(i int)    { b[i/64] &^= 1 << (i % 64) }
+func (b bitset) has(i int) bool { return b[i/64]&(1<<(i%64)) != 0 }
+func (b bitset) copy() bitset   { return append(bitset(nil), b...) }
+func (b bitset) andNot(o bitset) {
+	for w := range b {
+		b[w] &^= o[w]
+	}
+}
+
+func (b bitset) empty() bool {
+	for _, word := range b {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// 与o的交集大小
+func (b bitset) andCount(o bitset) int {
+	n := 0
+	for w := range b {
+		n += bits.OnesCount64(b[w] & o[w])
+	}
+	return n
+}
+
+// 按下标升序遍历集合中的点
+func (b bitset) each(f func(i int)) {
+	for w, word := range b {
+		for word != 0 {
+			i := bits.TrailingZeros64(word)
+			f(w*64 + i)
+			word &= word - 1
+		}
+	}
+}
+
+// 按TxId排序后的下标、邻接位图和权重
+func indexGraph(graph *conflictgraph.UndirectedGraph, weight VertexWeight) ([]uint, []bitset, []uint64) {
+	ids := make([]uint, 0, len(graph.Vertices))
+	for id := range graph.Vertices {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	index := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	adj := make([]bitset, len(ids))
+	weights := make([]uint64, len(ids))
+	for i, id := range ids {
+		adj[i] = newBitset(len(ids))
+		for neighborId := range graph.AdjacencyMap[id] {
+			if j, ok := index[neighborId]; ok && j != i {
+				adj[i].set(j)
+			}
+		}
+		weights[i] = weight(graph.Vertices[id])
+	}
+	return ids, adj, weights
+}
+
+func toIds(ids []uint, res []int) []uint {
+	out := make([]uint, 0, len(res))
+	for _, i := range res {
+		out = append(out, ids[i])
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i] < out[j]
+	})
+	return out
+}
+
+type exactSolver struct {
+	adj      []bitset
+	best     []int
+	deadline time.Time
+	nodes    int
+	timeout  bool
+}
+
+// ExactMIS 分支定界求最大独立集，不修改传入的图。
+// 每个搜索节点先做度为0、1的规约，再用团覆盖数做上界剪枝，按度最大的点分支；
+// 超过budget时返回当前最好的解，第二个返回值为false。结果按TxId升序
+func ExactMIS(graph *conflictgraph.UndirectedGraph, budget time.Duration) ([]uint, bool) {
+	ids, adj, _ := indexGraph(graph, GasWeight)
+	s := &exactSolver{
+		adj:      adj,
+		deadline: time.Now().Add(budget),
+	}
+	p := newBitset(len(ids))
+	for i := range ids {
+		p.set(i)
+	}
+
+	// 先用贪心解作为下界，超时也总能返回一个合法的独立集
+	s.best = s.greedy(p.copy())
+	s.search(p, make([]int, 0, len(ids)))
+
+	return toIds(ids, s.best), !s.timeout
+}
+
+// 每次选剩余图中度最小的点
+func (s *exactSolver) greedy(p bitset) []int {
+	res := make([]int, 0)
+	for !p.empty() {
+		minDegree, minId := -1, -1
+		p.each(func(i int) {
+			if d := s.adj[i].andCount(p); minDegree < 0 || d < minDegree {
+				minDegree, minId = d, i
+			}
+		})
+		res = append(res, minId)
+		p.clear(minId)
+		p.andNot(s.adj[minId])
+	}
+	return res
+}
+
+// 贪心地把p划分成若干个团，每个团里最多选一个点，团数就是独立集大小的上界
+func (s *exactSolver) cliqueCover(p bitset) int {
+	commons := make([]bitset, 0)
+	p.each(func(i int) {
+		for _, common := range commons {
+			if common.has(i) {
+				for w := range common {
+					common[w] &= s.adj[i][w]
+				}
+				return
+			}
+		}
+		commons = append(commons, s.adj[i].copy())
+	})
+	return len(commons)
+}
+
+func (s *exactSolver) search(p bitset, cur []int) {
+	s.nodes++
+	if s.nodes%1024 == 0 && time.Now().After(s.deadline) {
+		s.timeout = true
+	}
+	if s.timeout {
+		return
+	}
+
+	// 规约: 度为0的点直接选，度为1的点选它自己、删掉它的邻居，都不会让解变差
+	for changed := true; changed; {
+		changed = false
+		p.each(func(i int) {
+			if !p.has(i) {
+				return
+			}
+			switch s.adj[i].andCount(p) {
+			case 0:
+				cur = append(cur, i)
+				p.clear(i)
+				changed = true
+			case 1:
+				cur = append(cur, i)
+				p.clear(i)
+				p.andNot(s.adj[i])
+				changed = true
+			}
+		})
+	}
+	if p.empty() {
+		if len(cur) > len(s.best) {
+			s.best = append([]int(nil), cur...)
+		}
+		return
+	}
+	if len(cur)+s.cliqueCover(p) <= len(s.best) {
+		return
+	}
+
+	// 度最大的点分支，度相同时取下标最小的
+	maxDegree, v := -1, -1
+	p.each(func(i int) {
+		if d := s.adj[i].andCount(p); d > maxDegree {
+			maxDegree, v = d, i
+		}
+	})
+	include := p.copy()
+	include.clear(v)
+	include.andNot(s.adj[v])
+	s.search(include, append(cur, v))
+
+	exclude := p.copy()
+	exclude.clear(v)
+	s.search(exclude, cur)
+}
@@ -0,0 +1,142 @@
+package mis
+
+import (
+	conflictgraph "erigonInteract/conflictGraph"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// 枚举所有子集求最大独立集的大小
+func bruteForceMIS(graph *conflictgraph.UndirectedGraph, n int) int {
+	best := 0
+	for mask := 0; mask < 1<<n; mask++ {
+		size, ok := 0, true
+		for i := 0; i < n && ok; i++ {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			size++
+			for j := i + 1; j < n; j++ {
+				if mask&(1<<j) != 0 && graph.HasEdge(uint(i), uint(j)) {
+					ok = false
+					break
+				}
+			}
+		}
+		if ok && size > best {
+			best = size
+		}
+	}
+	return best
+}
+
+func TestExactMIS(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for iter := 0; iter < 200; iter++ {
+		n := 1 + r.Intn(14)
+		graph := randomGraph(r, n, r.Float64())
+		edgeNum := graph.EdgeNum()
+		res, optimal := ExactMIS(graph, time.Second)
+		if !optimal {
+			t.Fatal("small graph should not time out")
+		}
+		if expected := bruteForceMIS(graph, n); len(res) != expected {
+			t.Fatalf("unexpected mis size %d, expected %d", len(res), expected)
+		}
+		for i := range res {
+			for j := i + 1; j < len(res); j++ {
+				if graph.HasEdge(res[i], res[j]) {
+					t.Fatalf("%d and %d are adjacent", res[i], res[j])
+				}
+			}
+		}
+		if len(graph.Vertices) != n || graph.EdgeNum() != edgeNum {
+			t.Fatal("ExactMIS modified the graph")
+		}
+	}
+}
+
+func TestExactMISBudget(t *testing.T) {
+	// 预算为0时搜索很快停止，仍然返回合法解
+	graph := randomGraph(rand.New(rand.NewSource(3)), 200, 0.1)
+	res, _ := ExactMIS(graph, 0)
+	if len(res) == 0 {
+		t.Fatal("expected the greedy solution")
+	}
+	for i := range res {
+		for j := i + 1; j < len(res); j++ {
+			if graph.HasEdge(res[i], res[j]) {
+				t.Fatalf("%d and %d are adjacent", res[i], res[j])
+			}
+		}
+	}
+}
+
+// 枚举所有子集求最大权独立集的权重
+func bruteForceWeightedMIS(graph *conflictgraph.UndirectedGraph, n int) uint64 {
+	var best uint64
+	for mask := 0; mask < 1<<n; mask++ {
+		var sum uint64
+		ok := true
+		for i := 0; i < n && ok; i++ {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			sum += graph.Vertices[uint(i)].Cost
+			for j := i + 1; j < n; j++ {
+				if mask&(1<<j) != 0 && graph.HasEdge(uint(i), uint(j)) {
+					ok = false
+					break
+				}
+			}
+		}
+		if ok && sum > best {
+			best = sum
+		}
+	}
+	return best
+}
+
+func TestExactWeightedMIS(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	for iter := 0; iter < 200; iter++ {
+		n := 1 + r.Intn(14)
+		graph := randomGraph(r, n, r.Float64())
+		for _, v := range graph.Vertices {
+			v.Cost = uint64(21000 + r.Intn(1000000))
+		}
+		res, optimal := ExactWeightedMIS(graph, GasWeight, time.Second)
+		if !optimal {
+			t.Fatal("small graph should not time out")
+		}
+		var sum uint64
+		for i, u := range res {
+			sum += graph.Vertices[u].Cost
+			for _, v := range res[i+1:] {
+				if graph.HasEdge(u, v) {
+					t.Fatalf("%d and %d are adjacent", u, v)
+				}
+			}
+		}
+		if expected := bruteForceWeightedMIS(graph, n); sum != expected {
+			t.Fatalf("unexpected weight %d, expected %d", sum, expected)
+		}
+	}
+}
+
+func TestGreedyWeightedMIS(t *testing.T) {
+	// 星形图: 中心的gas比所有叶子加起来还大，应当只选中心
+	graph := conflictgraph.NewUndirectedGraph()
+	graph.AddVertexWithCost(0, 1000)
+	for id := uint(1); id < 5; id++ {
+		graph.AddVertexWithCost(id, 100)
+		graph.AddEdge(0, id)
+	}
+	if res := GreedyWeightedMIS(graph, GasWeight); len(res) != 1 || res[0] != 0 {
+		t.Fatalf("unexpected result %v", res)
+	}
+	if res, _ := ExactWeightedMIS(graph, GasWeight, time.Second); len(res) != 1 || res[0] != 0 {
+		t.Fatalf("unexpected exact result %v", res)
+	}
+}
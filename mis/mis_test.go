@@ -85,3 +85,26 @@ func TestSolveMIS(t *testing.T) {
 		}
 	}
 }
+
+// 出栈时隔一个加一个会选出相邻的点，这个图上大约十分之一的运行会出错
+func TestLinearTimeIndependent(t *testing.T) {
+	G := conflictgraph.NewUndirectedGraph()
+	for i := 0; i < 5; i++ {
+		G.AddVertex(uint(i))
+	}
+	for _, e := range [][2]uint{{0, 1}, {0, 3}, {0, 4}, {1, 4}, {2, 3}, {2, 4}} {
+		G.AddEdge(e[0], e[1])
+	}
+	for i := 0; i < 200; i++ {
+		solution := NewSolution(G.Copy())
+		solution.Solve()
+		set := solution.IndependentSet.ToSlice()
+		for j, u := range set {
+			for _, v := range set[j+1:] {
+				if G.HasEdge(u.(uint), v.(uint)) {
+					t.Fatalf("%d and %d are adjacent", u, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package mis
+
+import (
+	conflictgraph "erigonInteract/conflictGraph"
+	"sort"
+
+	set "github.com/deckarep/golang-set"
+)
+
+// bucket 按度数分桶的顶点集合，LinearTime用set.Set，DeterministicLinearTime用orderedSet
+type bucket interface {
+	Add(id uint)
+	Remove(id uint)
+	Contains(id uint) bool
+	Len() int
+	Pick() uint // 取一个点，不移除
+	ToSlice() []uint
+}
+
+// mapSet 把set.Set包装成bucket，Pick取到哪个点由底层的map决定
+type mapSet struct {
+	s set.Set
+}
+
+func (m mapSet) Add(id uint) {
+	m.s.Add(id)
+}
+
+func (m mapSet) Remove(id uint) {
+	m.s.Remove(id)
+}
+
+func (m mapSet) Contains(id uint) bool {
+	return m.s.Contains(id)
+}
+
+func (m mapSet) Len() int {
+	return m.s.Cardinality()
+}
+
+func (m mapSet) Pick() uint {
+	id := m.s.Pop().(uint)
+	m.s.Add(id)
+	return id
+}
+
+func (m mapSet) ToSlice() []uint {
+	ids := make([]uint, 0, m.s.Cardinality())
+	for _, id := range m.s.ToSlice() {
+		ids = append(ids, id.(uint))
+	}
+	return ids
+}
+
+// reducer LinearTime和DeterministicLinearTime共用的规约过程，
+// 两者只在分桶的实现和邻居的遍历顺序上不同
+type reducer struct {
+	graph                    *conflictgraph.UndirectedGraph
+	one, two, more, selected bucket
+	stack                    *VertexStack
+	ordered                  bool            // 邻居按TxId升序遍历
+	original                 map[uint][]uint // 规约前的邻接表，出栈时用来检查冲突
+}
+
+// 按度数把图中的点放进各个桶，并记下规约前的邻接表
+func newReducer(graph *conflictgraph.UndirectedGraph, one, two, more, selected bucket, stack *VertexStack, ordered bool) *reducer {
+	r := &reducer{
+		graph:    graph,
+		one:      one,
+		two:      two,
+		more:     more,
+		selected: selected,
+		stack:    stack,
+		ordered:  ordered,
+		original: make(map[uint][]uint, len(graph.Vertices)),
+	}
+	for k, v := range graph.Vertices {
+		r.original[k] = r.neighbors(k)
+		switch v.Degree {
+		case 0:
+			selected.Add(k)
+		case 1:
+			one.Add(k)
+		case 2:
+			two.Add(k)
+		default:
+			more.Add(k)
+		}
+	}
+	return r
+}
+
+func (r *reducer) neighbors(id uint) []uint {
+	ids := make([]uint, 0, len(r.graph.AdjacencyMap[id]))
+	for neighborId := range r.graph.AdjacencyMap[id] {
+		ids = append(ids, neighborId)
+	}
+	if r.ordered {
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+	}
+	return ids
+}
+
+func (r *reducer) solve() {
+	for r.one.Len() > 0 || r.two.Len() > 0 || r.more.Len() > 0 {
+		if r.one.Len() > 0 {
+			r.degreeOneReduction()
+		} else if r.two.Len() > 0 {
+			r.degreeTwoPathReduction()
+		} else {
+			r.inexactReduction()
+		}
+	}
+	// 出栈的点与原图中已选的点都不相邻时才加入；隔一个加一个在规约加过边时会选出相邻的点
+	for id := r.stack.Pop(); id != MAX_UINT; id = r.stack.Pop() {
+		conflict := false
+		for _, neighborId := range r.original[id] {
+			if r.selected.Contains(neighborId) {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			r.selected.Add(id)
+		}
+	}
+}
+
+// 更新Set的状态
+func (r *reducer) updateSet(neighbor *conflictgraph.Vertex) {
+	neighborId := neighbor.TxId
+	switch neighbor.Degree {
+	case 0:
+		r.selected.Add(neighborId)
+		r.one.Remove(neighborId)
+	case 1:
+		r.one.Add(neighborId)
+		r.two.Remove(neighborId)
+	case 2:
+		r.two.Add(neighborId)
+		r.more.Remove(neighborId)
+	}
+}
+
+func (r *reducer) deleteVertex(id uint) {
+	v, ok := r.graph.Vertices[id]
+	if !ok {
+		panic("怎么会找不到呢")
+	}
+	// for each neighbor w of v in G
+	for _, neighborId := range r.neighbors(id) {
+		neighbor, ok := r.graph.Vertices[neighborId]
+		if !ok {
+			panic("Unexpected neighborId")
+		}
+		neighbor.Degree--
+		delete(r.graph.AdjacencyMap[neighborId], id)
+		r.updateSet(neighbor)
+	}
+
+	// remove v from G, v1, v2, v3
+	switch v.Degree {
+	case 0:
+		break
+	case 1:
+		r.one.Remove(id)
+	case 2:
+		r.two.Remove(id)
+	default:
+		r.more.Remove(id)
+	}
+	delete(r.graph.Vertices, id)
+	delete(r.graph.AdjacencyMap, id)
+}
+
+// 取一个度为1的点，删掉它的邻居
+func (r *reducer) degreeOneReduction() {
+	txId := r.one.Pick()
+	for _, neighborId := range r.neighbors(txId) {
+		r.deleteVertex(neighborId)
+	}
+}
+
+// 删掉度最大的点，度相同时删ToSlice中靠前的
+func (r *reducer) inexactReduction() {
+	ids := r.more.ToSlice()
+	maxDegreeId := ids[0]
+	maxDegree := r.graph.Vertices[maxDegreeId].Degree
+	for _, txId := range ids {
+		if degree := r.graph.Vertices[txId].Degree; degree > maxDegree {
+			maxDegree = degree
+			maxDegreeId = txId
+		}
+	}
+	r.deleteVertex(maxDegreeId)
+}
+
+// 为Degree 2的端点找到不在path中的邻居
+func (r *reducer) getOutsideNeighbor(u uint) uint {
+	for _, neighborId := range r.neighbors(u) {
+		if r.graph.Vertices[neighborId].Degree != 2 {
+			return neighborId
+		}
+	}
+	return MAX_UINT
+}
+
+func (r *reducer) degreeTwoPathReduction() {
+	uId := r.two.Pick()
+	path, isCycle := r.findLongestDegreeTwoPath(uId)
+	if isCycle {
+		r.deleteVertex(uId)
+		return
+	}
+	path = r.pathReOrg(path)
+	// v, w不属于path，是path两端连接的，不属于path的点
+	var v, w uint = MAX_UINT, MAX_UINT
+	if len(path) == 1 {
+		// path只有一个元素时，v和w是它的两个不同的邻居
+		neighbors := r.neighbors(path[0])
+		v, w = neighbors[0], neighbors[1]
+	} else {
+		v = r.getOutsideNeighbor(path[0])
+		w = r.getOutsideNeighbor(path[len(path)-1])
+	}
+	if v == MAX_UINT || w == MAX_UINT {
+		panic("v or w is MAX_UINT")
+	}
+	if v == w {
+		r.deleteVertex(v)
+	} else if len(path)%2 == 1 {
+		if r.graph.HasEdge(v, w) {
+			r.deleteVertex(v)
+			r.deleteVertex(w)
+		} else {
+			// 保留path[0]，删掉path上其余的点，再连上path[0]和w；path[0]的度仍是2，留在V2中；
+			// 只有一个点时图没有变化，必须移出V2，否则会反复选中它
+			if len(path) == 1 {
+				r.two.Remove(path[0])
+			}
+			for i := 1; i < len(path); i++ {
+				r.graph.RemoveVertex(path[i])
+				r.two.Remove(path[i])
+			}
+			r.graph.AddEdge(path[0], w)
+			// push vl(path[-1]),...,v2(path[1]) into S
+			for i := len(path) - 1; i > 0; i-- {
+				r.stack.Push(path[i])
+			}
+		}
+	} else {
+		// 删掉整条path，v和w之间没有边时连上
+		for _, point := range path {
+			r.graph.RemoveVertex(point)
+			r.two.Remove(point)
+		}
+		if !r.graph.HasEdge(v, w) {
+			// 这个情况下v,w的度没有变
+			r.graph.AddEdge(v, w)
+		} else {
+			// 这个情况下v,w的度都减一了，要更新一下Set状态
+			r.updateSet(r.graph.Vertices[v])
+			r.updateSet(r.graph.Vertices[w])
+		}
+		// push vl,...,v1 into S
+		for i := len(path) - 1; i >= 0; i-- {
+			r.stack.Push(path[i])
+		}
+	}
+}
+
+// 从一个点出发，找到他属于的最长的degree为2的路径，路径还没有排序，即还不知道谁是两头的点
+func (r *reducer) findLongestDegreeTwoPath(vId uint) ([]uint, bool) {
+	visited := make(map[uint]bool)
+	longestPath := make([]uint, 0)
+	r.dfsToFindDegreeTwoPath(vId, visited, &longestPath)
+
+	// 是degree 2环时每个点的邻居都被访问过
+	for _, id := range longestPath {
+		for neighborId := range r.graph.AdjacencyMap[id] {
+			if !visited[neighborId] {
+				return longestPath, false
+			}
+		}
+	}
+	return longestPath, true
+}
+
+func (r *reducer) dfsToFindDegreeTwoPath(vId uint, visited map[uint]bool, path *[]uint) {
+	visited[vId] = true
+	*path = append(*path, vId)
+	for _, neighborId := range r.neighbors(vId) {
+		if !visited[neighborId] && r.graph.Vertices[neighborId].Degree == 2 {
+			r.dfsToFindDegreeTwoPath(neighborId, visited, path)
+		}
+	}
+}
+
+// 从id较小的端点出发重排路径，路径的方向与遍历顺序无关
+func (r *reducer) pathReOrg(initPath []uint) []uint {
+	inPath := make(map[uint]bool)
+	var st = MAX_UINT
+	for _, v := range initPath {
+		inPath[v] = true
+		if v >= st {
+			continue
+		}
+		for neighborId := range r.graph.AdjacencyMap[v] {
+			if r.graph.Vertices[neighborId].Degree != 2 {
+				st = v
+				break
+			}
+		}
+	}
+
+	path := make([]uint, 0, len(initPath))
+	visited := make(map[uint]bool)
+	r.dfsToReOrgPath(st, visited, inPath, &path)
+	return path
+}
+
+func (r *reducer) dfsToReOrgPath(v uint, visited map[uint]bool, inPath map[uint]bool, path *[]uint) {
+	visited[v] = true
+	*path = append(*path, v)
+	for _, neighborId := range r.neighbors(v) {
+		if !visited[neighborId] && inPath[neighborId] {
+			r.dfsToReOrgPath(neighborId, visited, inPath, path)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package mis
+
+import (
+	conflictgraph "erigonInteract/conflictGraph"
+	"time"
+)
+
+// VertexWeight 最大权独立集中顶点的权重
+type VertexWeight func(v *conflictgraph.Vertex) uint64
+
+// GasWeight 以顶点代价(交易的gas)为权重
+func GasWeight(v *conflictgraph.Vertex) uint64 {
+	return v.Cost
+}
+
+// 每次选 weight/(degree+1) 最大的点(GWMIN)，比值相同时取度小的、下标小的
+func greedyWeighted(adj []bitset, weights []uint64, p bitset) []int {
+	res := make([]int, 0)
+	for !p.empty() {
+		best, bestDegree := -1, 0
+		p.each(func(i int) {
+			d := adj[i].andCount(p)
+			if best < 0 {
+				best, bestDegree = i, d
+				return
+			}
+			lhs := weights[i] * uint64(bestDegree+1)
+			rhs := weights[best] * uint64(d+1)
+			if lhs > rhs || (lhs == rhs && d < bestDegree) {
+				best, bestDegree = i, d
+			}
+		})
+		res = append(res, best)
+		p.clear(best)
+		p.andNot(adj[best])
+	}
+	return res
+}
+
+// GreedyWeightedMIS 贪心的最大权独立集，不修改传入的图，结果按TxId升序
+func GreedyWeightedMIS(graph *conflictgraph.UndirectedGraph, weight VertexWeight) []uint {
+	ids, adj, weights := indexGraph(graph, weight)
+	p := newBitset(len(ids))
+	for i := range ids {
+		p.set(i)
+	}
+	return toIds(ids, greedyWeighted(adj, weights, p))
+}
+
+type weightedSolver struct {
+	adj        []bitset
+	weights    []uint64
+	best       []int
+	bestWeight uint64
+	deadline   time.Time
+	nodes      int
+	timeout    bool
+}
+
+// ExactWeightedMIS 分支定界求最大权独立集，与ExactMIS的搜索方式相同，
+// 上界为贪心团覆盖中每个团的最大权重之和；超过budget时返回当前最好的解，第二个返回值为false
+func ExactWeightedMIS(graph *conflictgraph.UndirectedGraph, weight VertexWeight, budget time.Duration) ([]uint, bool) {
+	ids, adj, weights := indexGraph(graph, weight)
+	p := newBitset(len(ids))
+	for i := range ids {
+		p.set(i)
+	}
+	s := &weightedSolver{
+		adj:      adj,
+		weights:  weights,
+		deadline: time.Now().Add(budget),
+	}
+	s.best = greedyWeighted(adj, weights, p.copy())
+	s.bestWeight = s.weightOf(s.best)
+	s.search(p, make([]int, 0, len(ids)), 0)
+	return toIds(ids, s.best), !s.timeout
+}
+
+func (s *weightedSolver) weightOf(set []int) uint64 {
+	var sum uint64
+	for _, i := range set {
+		sum += s.weights[i]
+	}
+	return sum
+}
+
+func (s *weightedSolver) cliqueCoverWeight(p bitset) uint64 {
+	commons := make([]bitset, 0)
+	maxWeights := make([]uint64, 0)
+	p.each(func(i int) {
+		for c, common := range commons {
+			if common.has(i) {
+				for w := range common {
+					common[w] &= s.adj[i][w]
+				}
+				maxWeights[c] = max(maxWeights[c], s.weights[i])
+				return
+			}
+		}
+		commons = append(commons, s.adj[i].copy())
+		maxWeights = append(maxWeights, s.weights[i])
+	})
+	var sum uint64
+	for _, w := range maxWeights {
+		sum += w
+	}
+	return sum
+}
+
+func (s *weightedSolver) search(p bitset, cur []int, curWeight uint64) {
+	s.nodes++
+	if s.nodes%1024 == 0 && time.Now().After(s.deadline) {
+		s.timeout = true
+	}
+	if s.timeout {
+		return
+	}
+
+	// 规约: 度为0的点直接选；度为1且权重不小于唯一邻居的点选它自己、删掉邻居
+	for changed := true; changed; {
+		changed = false
+		p.each(func(i int) {
+			if !p.has(i) {
+				return
+			}
+			switch s.adj[i].andCount(p) {
+			case 0:
+				cur = append(cur, i)
+				curWeight += s.weights[i]
+				p.clear(i)
+				changed = true
+			case 1:
+				neighbor := -1
+				p.each(func(j int) {
+					if s.adj[i].has(j) {
+						neighbor = j
+					}
+				})
+				if s.weights[i] >= s.weights[neighbor] {
+					cur = append(cur, i)
+					curWeight += s.weights[i]
+					p.clear(i)
+					p.clear(neighbor)
+					changed = true
+				}
+			}
+		})
+	}
+	if p.empty() {
+		if curWeight > s.bestWeight {
+			s.best = append([]int(nil), cur...)
+			s.bestWeight = curWeight
+		}
+		return
+	}
+	if curWeight+s.cliqueCoverWeight(p) <= s.bestWeight {
+		return
+	}
+
+	maxDegree, v := -1, -1
+	p.each(func(i int) {
+		if d := s.adj[i].andCount(p); d > maxDegree {
+			maxDegree, v = d, i
+		}
+	})
+	include := p.copy()
+	include.clear(v)
+	include.andNot(s.adj[v])
+	s.search(include, append(cur, v), curWeight+s.weights[v])
+
+	exclude := p.copy()
+	exclude.clear(v)
+	s.search(exclude, cur, curWeight)
+}
@@ -0,0 +1,50 @@
+package oldmis
+
+import (
+	conflictgraph "erigonInteract/conflictGraph"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+// 把合成的冲突图转成旧的图结构
+func fromUndirected(g *conflictgraph.UndirectedGraph) *OldUndirectedGraph {
+	old := OldNewUndirectedGraph()
+	for id := range g.Vertices {
+		old.OldAddVertex(common.Hash{}, id)
+	}
+	for id, neighbors := range g.AdjacencyMap {
+		for neighborId := range neighbors {
+			old.OldAddEdge(id, neighborId)
+		}
+	}
+	return old
+}
+
+func TestSyntheticOldSolveMISInTurn(t *testing.T) {
+	for _, ng := range conflictgraph.SyntheticGraphs(300) {
+		if err := ng.Graph.ValidateRounds(OldSolveMISInTurn(fromUndirected(ng.Graph))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOldSolveMISInTurn(b *testing.B) {
+	for _, ng := range conflictgraph.SyntheticGraphs(300) {
+		g := ng.Graph
+		b.Run(ng.Name, func(b *testing.B) {
+			rounds := OldSolveMISInTurn(fromUndirected(g))
+			if err := g.ValidateRounds(rounds); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(len(rounds)), "rounds")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				old := fromUndirected(g)
+				b.StartTimer()
+				OldSolveMISInTurn(old)
+			}
+		})
+	}
+}
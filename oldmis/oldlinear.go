@@ -74,13 +74,17 @@ func (s *LinearTime) Solve() {
 			s.inexactReduction()
 		}
 	}
-	canAdd := true
+	// 出栈的点与已选的点都不相邻时才加入；AdjacencyMap只增不减，包含了原图的所有边
 	for id := s.Stack.Pop(); id != MAX_UINT; id = s.Stack.Pop() {
-		if canAdd {
+		conflict := false
+		for _, neighborId := range s.Graph.AdjacencyMap[id] {
+			if s.IndependentSet.Contains(neighborId) {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
 			s.IndependentSet.Add(id)
-			canAdd = false
-		} else {
-			canAdd = true
 		}
 	}
 }
@@ -216,7 +220,12 @@ func (s *LinearTime) degreeTwoPathReduction() {
 					s.VerticesTwo.Remove(path[i])
 				}
 				// and add edge bwteen v1(path[0]) and w
-				s.Graph.OldAddEdge(path[0], w)
+				// path只有一个点时path[0]和w本来就相邻；否则新边替换了被删掉的边，把OldAddEdge加上的度数减回来
+				if len(path) > 1 {
+					s.Graph.OldAddEdge(path[0], w)
+					s.Graph.Vertices[path[0]].Degree--
+					s.Graph.Vertices[w].Degree--
+				}
 				// push vl(path[-1]),...,v2(path[1]) into S
 				for i := len(path) - 1; i > 0; i-- {
 					s.Stack.Push(path[i])
@@ -234,6 +243,9 @@ func (s *LinearTime) degreeTwoPathReduction() {
 			if !s.Graph.OldHasEdge(v, w) {
 				// 因为加了边，所以v,w度数不变
 				s.Graph.OldAddEdge(v, w)
+				// OldAddEdge会让度数加一，要减回来
+				s.Graph.Vertices[v].Degree--
+				s.Graph.Vertices[w].Degree--
 			} else {
 				// 因为没有加边，所以v,w度数都减一
 				s.minusDegree(v)
@@ -305,7 +317,8 @@ func (s *LinearTime) pathReOrg(initPath []uint) []uint {
 				}
 			}
 		} else {
-			break
+			// 与mis包一致，走完所有的initPath生成inPath，提前break会截断路径
+			continue
 		}
 	}
 
@@ -0,0 +1,46 @@
+package oldmis
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+func newOldGraph(n int, edges [][2]uint) *OldUndirectedGraph {
+	G := OldNewUndirectedGraph()
+	for i := 0; i < n; i++ {
+		G.OldAddVertex(common.Hash{}, uint(i))
+	}
+	for _, e := range edges {
+		G.OldAddEdge(e[0], e[1])
+	}
+	return G
+}
+
+func TestOldLinearTime(t *testing.T) {
+	cases := []struct {
+		n     int
+		edges [][2]uint
+	}{
+		// 出栈时隔一个加一个会选出相邻的点
+		{5, [][2]uint{{0, 1}, {0, 3}, {0, 4}, {1, 4}, {2, 3}, {2, 4}}},
+		// 路径规约后度数多算了一，之后找不到路径端点外的邻居而panic
+		{6, [][2]uint{{0, 3}, {0, 4}, {0, 5}, {1, 2}, {1, 5}, {2, 3}, {3, 4}}},
+	}
+	for _, c := range cases {
+		// Set底层是Map，每次运行的顺序不同，多跑几次
+		for i := 0; i < 100; i++ {
+			G := newOldGraph(c.n, c.edges)
+			solution := NewSolution(G)
+			solution.Solve()
+			set := solution.IndependentSet.ToSlice()
+			for j, u := range set {
+				for _, v := range set[j+1:] {
+					if newOldGraph(c.n, c.edges).OldHasEdge(u.(uint), v.(uint)) {
+						t.Fatalf("%d and %d are adjacent", u, v)
+					}
+				}
+			}
+		}
+	}
+}
@@ -2,7 +2,10 @@ package schedule
 
 import (
 	"context"
+	"crypto/sha256"
 	"erigonInteract/accesslist"
+	conflictgraph "erigonInteract/conflictGraph"
+	"erigonInteract/mis"
 	interactState "erigonInteract/state"
 	"erigonInteract/tracer"
 	"erigonInteract/utils"
@@ -21,11 +24,11 @@ import (
 )
 
 type ScheduleRes struct {
-	Flag      uint // 1 for CC , 2 for DAG, 3 for MIS
+	Flag      uint // 1 for CC , 2 for DAG, 3 for MIS, 5 for coloring (4 marks the end of the pipeline)
 	cost      uint64
 	txsGroups []types.Transactions
 	rwsets    []accesslist.RWSetList
-	groups    [][]uint
+	groups    [][]uint // CC时为连通分量，DAG/MIS时为每一轮执行的交易
 
 	txs types.Transactions
 
@@ -39,6 +42,12 @@ type ScheduleRes struct {
 	scheduleTime int64
 }
 
+// Checksum 调度方案的sha256，用于比较不同运行、不同机器上的调度结果
+func (sr *ScheduleRes) Checksum() [32]byte {
+	groupsSum := conflictgraph.GroupsChecksum(sr.groups)
+	return sha256.Sum256(append([]byte{byte(sr.Flag)}, groupsSum[:]...))
+}
+
 type PipeLineExecutor struct {
 	Sch chan *ScheduleRes
 }
@@ -64,7 +73,10 @@ func (pe *PipeLineExecutor) PipeLineExecLoop(wg *sync.WaitGroup) error {
 				totalTime = totalTime + executeTime + sr.scheduleTime
 				log.Info("SCC done", "blockNum", sr.blockNum, "executeTime", executeTime)
 			case 2:
-				executeTime, _ := SDAG(sr)
+				executeTime, err := SDAG(sr)
+				if err != nil {
+					log.Error("SDAG refused", "blockNum", sr.blockNum, "err", err)
+				}
 				totalTime = totalTime + executeTime + sr.scheduleTime
 				log.Info("SDAG done", "blockNum", sr.blockNum, "executeTime", executeTime)
 
@@ -73,6 +85,12 @@ func (pe *PipeLineExecutor) PipeLineExecLoop(wg *sync.WaitGroup) error {
 				totalTime = totalTime + executeTime + sr.scheduleTime
 				log.Info("SMIS done", "blockNum", sr.blockNum, "executeTime", executeTime)
 
+			case 5:
+				// 着色得到的轮次与MIS的形状相同，执行方式也相同
+				executeTime, _ := SMIS(sr)
+				totalTime = totalTime + executeTime + sr.scheduleTime
+				log.Info("SColor done", "blockNum", sr.blockNum, "executeTime", executeTime)
+
 			case 4:
 				wg.Done()
 				fmt.Println("Apex exec 10 blocks total time:", totalTime)
@@ -150,7 +168,7 @@ func (pe *PipeLineExecutor) PipeLineExec(blockReader *freezeblocks.BlockReader,
 		scheduleTime := time.Since(st)
 		sr.scheduleTime = int64(scheduleTime.Microseconds())
 
-		log.Info("schedule done", "blockNum", i, "scheduleTime", sr.scheduleTime)
+		log.Info("schedule done", "blockNum", i, "scheduleTime", sr.scheduleTime, "flag", sr.Flag, "checksum", fmt.Sprintf("%x", sr.Checksum()))
 
 		pe.Sch <- sr
 	}
@@ -168,16 +186,18 @@ func (pe *PipeLineExecutor) PipeLineExec(blockReader *freezeblocks.BlockReader,
 func Schedule(txs types.Transactions, predictRwSets []*accesslist.RWSet, rwAccessedBy *accesslist.RwAccessedBy) *ScheduleRes {
 
 	var wg sync.WaitGroup
-	resultCh := make(chan ScheduleRes, 3)
-	wg.Add(2)
+	resultCh := make(chan ScheduleRes, 4)
+	wg.Add(3)
 
 	// fmt.Println("CC")
 	go CC(txs, predictRwSets, rwAccessedBy, &wg, resultCh)
 	// fmt.Println("DAG")
 	go DAG(txs, rwAccessedBy, &wg, resultCh)
+	// MIS和着色共用一张无向图，两者都不修改它
+	graph := utils.GenerateUndiGraph(txs, rwAccessedBy)
 	// fmt.Println("MIS")
-	// go MIS(txs, rwAccessedBy, &wg, resultCh)
-	// go MIS(txs, predictRwSets, &wg, resultCh)
+	// go MIS(txs, graph, &wg, resultCh)
+	go Color(txs, graph, &wg, resultCh)
 
 	wg.Wait()
 	close(resultCh)
@@ -187,7 +207,8 @@ func Schedule(txs types.Transactions, predictRwSets []*accesslist.RWSet, rwAcces
 	var minCost uint64 = math.MaxUint64
 	finalRes := new(ScheduleRes)
 	for res := range resultCh {
-		if res.cost < minCost {
+		// cost相同时取Flag较小的方案，结果与goroutine完成的先后无关
+		if res.cost < minCost || (res.cost == minCost && res.Flag < finalRes.Flag) {
 			minCost = res.cost
 			temp := res
 			finalRes = &temp
@@ -200,20 +221,14 @@ func Schedule(txs types.Transactions, predictRwSets []*accesslist.RWSet, rwAcces
 // CC 连通分量调度估算
 func CC(txs types.Transactions, predictRwSets []*accesslist.RWSet, rwAccessedBy *accesslist.RwAccessedBy, wg *sync.WaitGroup, resultCh chan<- ScheduleRes) {
 	defer wg.Done()
-	// 无向图
+	// 连通分量只需要并查集，不用建图
 	vertexGroup := utils.GenerateVertexIdGroups(txs, rwAccessedBy)
 	// 分组
 	txsGroup, RWSetsGroup := utils.GenerateCCGroups(vertexGroup, txs, predictRwSets)
-	// 获取最大cost
+	// 获取最大cost, 每个连通分量内部串行
 	var maxCost uint64
-	for i := 0; i < len(txsGroup); i++ {
-		var temp uint64
-		for j := 0; j < len(txsGroup[i]); j++ {
-			temp = temp + txsGroup[i][j].GetGas()
-		}
-		if temp > maxCost {
-			maxCost = temp
-		}
+	for i := 0; i < len(vertexGroup); i++ {
+		maxCost = max(maxCost, utils.SumGas(txs, vertexGroup[i]))
 	}
 	// fmt.Println("cc maxCost:", maxCost)
 	// 构造返回结构体
@@ -223,7 +238,7 @@ func CC(txs types.Transactions, predictRwSets []*accesslist.RWSet, rwAccessedBy
 		txsGroups:    txsGroup,
 		txs:          txs,
 		rwsets:       RWSetsGroup,
-		groups:       nil,
+		groups:       vertexGroup,
 		header:       nil,
 		scatterState: nil,
 		blkCtx:       evmtypes.BlockContext{},
@@ -235,18 +250,17 @@ func CC(txs types.Transactions, predictRwSets []*accesslist.RWSet, rwAccessedBy
 // DAG 有向无环图调度估算
 func DAG(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy, wg *sync.WaitGroup, resultCh chan<- ScheduleRes) {
 	defer wg.Done()
-	groups := utils.GenerateTopoGroups(txs, rwAccessedBy)
-	// 获取最大cost
+	graph := utils.GenerateDiGraph(txs, rwAccessedBy)
+	groups, err := graph.GetTopo()
+	// SDAG每层执行完才开始下一层，cost按每层最重的交易求和；关键路径只是下界，不能用来和其他方案比较
 	var maxCost uint64
-	maxCost = 0
 	for i := 0; i < len(groups); i++ {
-		temp := txs[groups[i][0]].GetGas()
-		for j := 1; j < len(groups[i]); j++ {
-			if temp < txs[groups[i][j]].GetGas() {
-				temp = txs[groups[i][j]].GetGas()
-			}
-		}
-		maxCost += temp
+		maxCost += graph.MaxCost(groups[i])
+	}
+	if err != nil {
+		// 有环时分层不完整，不能选这个方案
+		log.Error("DAG schedule failed", "err", err)
+		maxCost = math.MaxUint64
 	}
 	// fmt.Println("dag maxCost:", maxCost)
 	// 构造返回结构体
@@ -265,22 +279,16 @@ func DAG(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy, wg *sync
 }
 
 // MIS 最大独立集调度估算
-func MIS(txs types.Transactions, predictRwSets []*accesslist.RWSet, wg *sync.WaitGroup, resultCh chan<- ScheduleRes) {
+func MIS(txs types.Transactions, graph *conflictgraph.UndirectedGraph, wg *sync.WaitGroup, resultCh chan<- ScheduleRes) {
 	defer wg.Done()
-	// 分组
-	// groups := utils.GenerateMISGroups(txs, rwAccessedBy)
-	groups := utils.GenerateOldMISGroups(txs, predictRwSets)
-	// 获取最大cost
-	var maxCost uint64
-	maxCost = 0
-	for i := 0; i < len(groups); i++ {
-		temp := txs[groups[i][0]].GetGas()
-		for j := 1; j < len(groups[i]); j++ {
-			if temp < txs[groups[i][j]].GetGas() {
-				temp = txs[groups[i][j]].GetGas()
-			}
-		}
-		maxCost += temp
+	// 分组, SolveMISInTurn会删除顶点, 所以在副本上求解
+	groups := utils.SolveMISInTurn(graph.Copy())
+	// 获取最大cost, 每一轮内部并行
+	maxCost := utils.RoundsCost(graph, groups)
+	// 按gas加权的分组把重交易放在同一轮，总代价更小时改用它
+	weightedGroups := utils.SolveWeightedMISInTurn(graph.Copy(), mis.GasWeight)
+	if weightedCost := utils.RoundsCost(graph, weightedGroups); weightedCost < maxCost {
+		groups, maxCost = weightedGroups, weightedCost
 	}
 	// fmt.Println("mis maxCost:", maxCost)
 	// 构造返回结构体
@@ -298,6 +306,24 @@ func MIS(txs types.Transactions, predictRwSets []*accesslist.RWSet, wg *sync.Wai
 	resultCh <- Res
 }
 
+// Color 图着色调度估算，每种颜色一轮，轮内并行
+func Color(txs types.Transactions, graph *conflictgraph.UndirectedGraph, wg *sync.WaitGroup, resultCh chan<- ScheduleRes) {
+	defer wg.Done()
+	groups := utils.SolveColorRounds(graph)
+	Res := ScheduleRes{
+		Flag:         5,
+		cost:         utils.RoundsCost(graph, groups),
+		txsGroups:    nil,
+		txs:          txs,
+		rwsets:       nil,
+		groups:       groups,
+		header:       nil,
+		scatterState: nil,
+		blkCtx:       evmtypes.BlockContext{},
+	}
+	resultCh <- Res
+}
+
 func SCC(sr *ScheduleRes) (int64, error) {
 	// 准备线程池
 	var antsWG sync.WaitGroup
@@ -314,6 +340,10 @@ func SCC(sr *ScheduleRes) (int64, error) {
 }
 
 func SDAG(sr *ScheduleRes) (int64, error) {
+	// 分层不完整时会漏掉交易，拒绝执行
+	if err := conflictgraph.ValidateGroups(sr.groups, len(sr.txs)); err != nil {
+		return 0, err
+	}
 	// 准备线程池
 	var antsWG sync.WaitGroup
 	antsPool, _ := ants.NewPool(64, ants.WithPreAlloc(true))
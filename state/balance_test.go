@@ -0,0 +1,63 @@
+package state
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+)
+
+// 两笔只做AddBalance的交易被并发执行时，两边的加法都要保留
+func addInParallel(ibs evmtypes.IntraBlockState, addr common.Address, n int) {
+	var wg sync.WaitGroup
+	for tx := 0; tx < 2; tx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				ibs.AddBalance(addr, uint256.NewInt(1))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestScatterStateParallelAddBalance(t *testing.T) {
+	coinbase := common.HexToAddress("0xc0")
+	s := NewScatterState()
+	s.CreateAccount(coinbase, false)
+	s.SetBalance(coinbase, uint256.NewInt(100))
+
+	addInParallel(s, coinbase, 1000)
+	if got := s.GetBalance(coinbase).Uint64(); got != 2100 {
+		t.Fatalf("expected 2100, got %d", got)
+	}
+}
+
+func TestOuterStateParallelAddBalance(t *testing.T) {
+	coinbase := common.HexToAddress("0xc0")
+	sdb := NewScatterState()
+	sdb.CreateAccount(coinbase, false)
+	sdb.SetBalance(coinbase, uint256.NewInt(100))
+	os := NewOuterState(NewGlobalVersionChain(), sdb)
+
+	// 第一次加法时本地还没有记录，要从ScatterState取原值
+	addInParallel(os, coinbase, 1000)
+	if got := os.GetBalance(coinbase).Uint64(); got != 2100 {
+		t.Fatalf("expected 2100, got %d", got)
+	}
+	if got := sdb.GetBalance(coinbase).Uint64(); got != 100 {
+		t.Fatalf("scatter state modified: %d", got)
+	}
+}
+
+func TestScatterStateDeltaNotPrefetched(t *testing.T) {
+	s := NewScatterState()
+	addr := common.HexToAddress("0x01")
+	s.AddBalance(addr, uint256.NewInt(1))
+	if _, ok := s.Balances.Load(addr); ok {
+		t.Fatal("delta on an unprefetched address should not invent a balance")
+	}
+}
@@ -120,9 +120,10 @@ func (fs *StateWithRwSets) CreateAccount(addr common.Address, contractCreation b
 	fs.StateDb.CreateAccount(addr, contractCreation)
 }
 
+// AddBalance 只做加法，记为可交换的加法写
 func (fs *StateWithRwSets) AddBalance(addr common.Address, amount *uint256.Int) {
 	if fs.RwSets != nil {
-		fs.RwSets.AddWriteSet(addr, accesslist.BALANCE)
+		fs.RwSets.AddDeltaSet(addr, accesslist.BALANCE)
 	}
 	fs.StateDb.AddBalance(addr, amount)
 }
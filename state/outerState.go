@@ -58,17 +58,30 @@ func (os *OuterState) CreateAccount(addr common.Address, contractCreation bool)
 }
 
 func (os *OuterState) SubBalance(addr common.Address, value *uint256.Int) {
-	data := os.GetBalance(addr)
-	balance := new(uint256.Int).Sub(data, value)
-	os.Balances.Store(addr, balance)
+	os.mergeBalanceDelta(addr, value, true)
 }
 
 func (os *OuterState) AddBalance(addr common.Address, value *uint256.Int) {
+	os.mergeBalanceDelta(addr, value, false)
+}
 
-	data := os.GetBalance(addr)
-
-	balance := new(uint256.Int).Add(data, value)
-	os.Balances.Store(addr, balance)
+// 和ScatterState一样用CAS循环合并delta，本地还没有记录时先把gvc/scatter中的值放到本地
+func (os *OuterState) mergeBalanceDelta(addr common.Address, value *uint256.Int, sub bool) {
+	for {
+		data, exists := os.Balances.Load(addr)
+		if !exists {
+			data, _ = os.Balances.LoadOrStore(addr, os.GetBalance(addr))
+		}
+		balance := new(uint256.Int)
+		if sub {
+			balance.Sub(data.(*uint256.Int), value)
+		} else {
+			balance.Add(data.(*uint256.Int), value)
+		}
+		if os.Balances.CompareAndSwap(addr, data, balance) {
+			return
+		}
+	}
 }
 
 func (os *OuterState) GetBalance(addr common.Address) *uint256.Int {
@@ -77,23 +90,17 @@ func (os *OuterState) GetBalance(addr common.Address) *uint256.Int {
 	if exists {
 		return data.(*uint256.Int)
 	}
-	if !exists {
-		// 尝试从gvc取，如果取不到再从scatter取
-		vc := os.gvc.getBalanceHead(addr)
-		if vc != nil {
-			if vc.Data != nil {
-				data = vc.Data
-				return data.(*uint256.Int)
-			}
-		} else {
-			data, exists = os.sdb.Balances.Load(addr)
-			if !exists { // 若这一步还未取到则返回0
-				return uint256.NewInt(0)
-			}
-			return data.(*uint256.Int)
-		}
+	// 尝试从gvc取，如果取不到再从scatter取；
+	// gvc的head总是存在，没有被Gria读过时Data为nil，表示值还在scatter中
+	vc := os.gvc.getBalanceHead(addr)
+	if vc != nil && vc.Data != nil {
+		return vc.Data.(*uint256.Int)
+	}
+	data, exists = os.sdb.Balances.Load(addr)
+	if !exists { // 若这一步还未取到则返回0
+		return uint256.NewInt(0)
 	}
-	return uint256.NewInt(0)
+	return data.(*uint256.Int)
 }
 
 func (os *OuterState) GetNonce(addr common.Address) uint64 {
@@ -377,6 +384,11 @@ func (os *OuterState) Prefetch(statedb evmtypes.IntraBlockState, rwSets accessli
 				os.prefetch(addr, hash, statedb)
 			}
 		}
+		for addr, State := range rwSet.DeltaSet {
+			for hash := range State {
+				os.prefetch(addr, hash, statedb)
+			}
+		}
 	}
 }
 
@@ -415,6 +427,11 @@ func (os *OuterState) Equal(statedb evmtypes.IntraBlockState, rwSets accesslist.
 				os.equal(addr, hash, statedb)
 			}
 		}
+		for addr, State := range rwSet.DeltaSet {
+			for hash := range State {
+				os.equal(addr, hash, statedb)
+			}
+		}
 	}
 }
 
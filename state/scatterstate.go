@@ -53,21 +53,33 @@ func (s *ScatterState) CreateAccount(addr common.Address, contractCreation bool)
 }
 
 func (s *ScatterState) SubBalance(addr common.Address, value *uint256.Int) {
-	balance, exists := s.Balances.Load(addr)
-	if !exists {
-		return
-	}
-	balance = new(uint256.Int).Sub(balance.(*uint256.Int), value)
-	s.Balances.Store(addr, balance)
+	s.mergeBalanceDelta(addr, value, true)
 }
 
 func (s *ScatterState) AddBalance(addr common.Address, value *uint256.Int) {
-	balance, exists := s.Balances.Load(addr)
-	if !exists {
-		return
+	s.mergeBalanceDelta(addr, value, false)
+}
+
+// 只做AddBalance的交易之间不算冲突，可能被并发执行，
+// 所以加减余额要用CAS循环合并delta，不能简单地Load再Store。
+// 地址没有被预取时不知道原来的余额，只能丢弃这个delta，打印出来便于排查预测的遗漏
+func (s *ScatterState) mergeBalanceDelta(addr common.Address, value *uint256.Int, sub bool) {
+	for {
+		balance, exists := s.Balances.Load(addr)
+		if !exists {
+			fmt.Println("balance delta dropped, address not prefetched:", addr.Hex(), "sub:", sub, "value:", value.String())
+			return
+		}
+		newBalance := new(uint256.Int)
+		if sub {
+			newBalance.Sub(balance.(*uint256.Int), value)
+		} else {
+			newBalance.Add(balance.(*uint256.Int), value)
+		}
+		if s.Balances.CompareAndSwap(addr, balance, newBalance) {
+			return
+		}
 	}
-	balance = new(uint256.Int).Add(balance.(*uint256.Int), value)
-	s.Balances.Store(addr, balance)
 }
 
 func (s *ScatterState) GetBalance(addr common.Address) *uint256.Int {
@@ -287,6 +299,11 @@ func (s *ScatterState) Prefetch(statedb evmtypes.IntraBlockState, rwSets accessl
 				s.prefetch(addr, hash, statedb)
 			}
 		}
+		for addr, State := range rwSet.DeltaSet {
+			for hash := range State {
+				s.prefetch(addr, hash, statedb)
+			}
+		}
 	}
 }
 
@@ -325,6 +342,11 @@ func (s *ScatterState) Equal(statedb evmtypes.IntraBlockState, rwSets accesslist
 				s.equal(addr, hash, statedb)
 			}
 		}
+		for addr, State := range rwSet.DeltaSet {
+			for hash := range State {
+				s.equal(addr, hash, statedb)
+			}
+		}
 	}
 }
 
@@ -176,7 +176,7 @@ func TestMakeGraph(t *testing.T) {
 	fmt.Println("Graph Edges", sum)
 
 	st = time.Now()
-	newGraph := utils.GenerateUndiGraph(len(txs), rwAccessedBy)
+	newGraph := utils.GenerateUndiGraph(txs, rwAccessedBy)
 	fmt.Println("New graph time", time.Since(st))
 
 	fmt.Println("New Graph Vertices", len(newGraph.Vertices))
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"erigonInteract/accesslist"
+	"fmt"
+	"os"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// 交易自带access list的覆盖率报告，只统计声明了access list的交易
+func AccessListTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) error {
+	file, err := os.Create(("accesslist.csv"))
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	err = writer.Write([]string{"BlockNum", "TxNum", "DeclaredTxs", "Subset", "Superset", "Exact", "Declared", "Touched", "Covered", "DeclaredAccounts", "CoveredAccounts"})
+	if err != nil {
+		panic(err)
+	}
+
+	var totalTxs, totalSubset, totalSuperset, totalExact int
+	fmt.Println("test start")
+	for i := 0; i < 500; i++ {
+		blockNum := blockNum + uint64(i)
+		fmt.Println("blockNum:", blockNum)
+		txCoverage, txNum, err := AccessListCoverage(blockReader, ctx, dbTx, blockNum)
+		if err != nil {
+			return err
+		}
+
+		var blockCoverage accesslist.AccessListCoverage
+		var subset, superset, exact int
+		for _, coverage := range txCoverage {
+			blockCoverage.Merge(coverage)
+			if coverage.IsSubset() {
+				subset++
+			}
+			if coverage.IsSuperset() {
+				superset++
+			}
+			if coverage.IsSubset() && coverage.IsSuperset() {
+				exact++
+			}
+		}
+		totalTxs += len(txCoverage)
+		totalSubset += subset
+		totalSuperset += superset
+		totalExact += exact
+
+		err = writer.Write([]string{fmt.Sprint(blockNum), fmt.Sprint(txNum), fmt.Sprint(len(txCoverage)), fmt.Sprint(subset), fmt.Sprint(superset), fmt.Sprint(exact), fmt.Sprint(blockCoverage.Declared), fmt.Sprint(blockCoverage.Touched), fmt.Sprint(blockCoverage.Covered), fmt.Sprint(blockCoverage.DeclaredAccounts), fmt.Sprint(blockCoverage.CoveredAccounts)})
+		if err != nil {
+			panic(err)
+		}
+	}
+	fmt.Println("txs with access list:", totalTxs, "subset:", totalSubset, "superset:", totalSuperset, "exact:", totalExact)
+	return nil
+}
+
+// AccessListCoverage 对比每笔声明了access list的交易与TrueRWSets，同时返回区块的交易数
+func AccessListCoverage(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) ([]accesslist.AccessListCoverage, int, error) {
+	blk, _ := GetBlockAndHeader(blockReader, ctx, dbTx, blockNum)
+	txs := blk.Transactions()
+	trueRwSets, err := TrueRWSets(blockReader, ctx, dbTx, blockNum)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res := make([]accesslist.AccessListCoverage, 0)
+	for i, tx := range txs {
+		al := tx.GetAccessList()
+		if len(al) == 0 {
+			continue
+		}
+		res = append(res, accesslist.CompareAccessList(al, trueRwSets[i]))
+	}
+	return res, len(txs), nil
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"erigonInteract/accesslist"
+	"fmt"
+	"os"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// 预测准确率报告，accuracy.csv按区块汇总，accuracy_tx.csv按交易展开
+func AccuracyTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) error {
+	blockfile, err := os.Create(("accuracy.csv"))
+	if err != nil {
+		panic(err)
+	}
+	defer blockfile.Close()
+	blockWriter := csv.NewWriter(blockfile)
+	defer blockWriter.Flush()
+
+	txfile, err := os.Create(("accuracy_tx.csv"))
+	if err != nil {
+		panic(err)
+	}
+	defer txfile.Close()
+	txWriter := csv.NewWriter(txfile)
+	defer txWriter.Flush()
+
+	// 每种key一行，"all"为所有种类之和
+	err = blockWriter.Write([]string{"BlockNum", "TxNum", "access", "kind", "TP", "FP", "FN", "precision", "recall"})
+	if err != nil {
+		panic(err)
+	}
+	err = txWriter.Write([]string{"BlockNum", "TxIdx", "access", "kind", "TP", "FP", "FN", "precision", "recall"})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("test start")
+	for i := 0; i < 500; i++ {
+		blockNum := blockNum + uint64(i)
+		fmt.Println("blockNum:", blockNum)
+		txAccuracy, err := PredictAccuracy(blockReader, ctx, dbTx, blockNum)
+		if err != nil {
+			return err
+		}
+
+		var blockAccuracy accesslist.Accuracy
+		for txIdx, accuracy := range txAccuracy {
+			blockAccuracy.Merge(accuracy)
+			for _, row := range accuracyRows(accuracy) {
+				err = txWriter.Write(append([]string{fmt.Sprint(blockNum), fmt.Sprint(txIdx)}, row...))
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+		for _, row := range accuracyRows(blockAccuracy) {
+			err = blockWriter.Write(append([]string{fmt.Sprint(blockNum), fmt.Sprint(len(txAccuracy))}, row...))
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	return nil
+}
+
+// PredictAccuracy 对比GetTxsAndPredicts与TrueRWSets，返回每笔交易的准确率
+func PredictAccuracy(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) ([]accesslist.Accuracy, error) {
+	_, predictRwSets, _ := GetTxsAndPredicts(blockReader, ctx, dbTx, blockNum)
+	trueRwSets, err := TrueRWSets(blockReader, ctx, dbTx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]accesslist.Accuracy, len(trueRwSets))
+	for i := range trueRwSets {
+		res[i] = accesslist.CompareRWSet(predictRwSets[i], trueRwSets[i])
+	}
+	return res, nil
+}
+
+// access, kind, TP, FP, FN, precision, recall; 全空的种类不输出
+func accuracyRows(accuracy accesslist.Accuracy) [][]string {
+	rows := make([][]string, 0)
+	appendRow := func(access, kind string, c accesslist.Confusion) {
+		rows = append(rows, []string{access, kind, fmt.Sprint(c.TP), fmt.Sprint(c.FP), fmt.Sprint(c.FN), fmt.Sprintf("%.4f", c.Precision()), fmt.Sprintf("%.4f", c.Recall())})
+	}
+
+	appendRow("read", "all", accuracy.ReadTotal())
+	for kind := accesslist.KeyKind(0); kind < accesslist.KindNum; kind++ {
+		if !accuracy.Read[kind].IsEmpty() {
+			appendRow("read", kind.String(), accuracy.Read[kind])
+		}
+	}
+	appendRow("write", "all", accuracy.WriteTotal())
+	for kind := accesslist.KeyKind(0); kind < accesslist.KindNum; kind++ {
+		if !accuracy.Write[kind].IsEmpty() {
+			appendRow("write", kind.String(), accuracy.Write[kind])
+		}
+	}
+	return rows
+}
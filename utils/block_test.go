@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"erigonInteract/accesslist"
+	"math/rand"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// 建图只用到交易的gas，其余方法不会被调用
+type testTx struct {
+	types.Transaction
+	gas uint64
+}
+
+func (tx testTx) GetGas() uint64 { return tx.gas }
+
+func testKey(addr, slot byte) accesslist.AccessKey {
+	return accesslist.AccessKey{Addr: common.BytesToAddress([]byte{addr}), Hash: common.BytesToHash([]byte{slot})}
+}
+
+func newTestRWSet(reads, writes, deltas []accesslist.AccessKey) *accesslist.RWSet {
+	set := accesslist.NewRWSet()
+	for _, key := range reads {
+		set.AddReadSet(key.Addr, key.Hash)
+	}
+	for _, key := range writes {
+		set.AddWriteSet(key.Addr, key.Hash)
+	}
+	for _, key := range deltas {
+		set.AddDeltaSet(key.Addr, key.Hash)
+	}
+	return set
+}
+
+// 由读写集构造一个区块，第i笔交易的gas为21000+i
+func newTestBlock(rwSets accesslist.RWSetList) (types.Transactions, *accesslist.RwAccessedBy) {
+	txs := make(types.Transactions, len(rwSets))
+	rwAccessedBy := accesslist.NewRwAccessedBy()
+	for i, set := range rwSets {
+		txs[i] = testTx{gas: 21000 + uint64(i)}
+		rwAccessedBy.Add(set, uint(i))
+	}
+	return txs, rwAccessedBy
+}
+
+// 随机读写集，key取自少量地址和slot以产生冲突；包含只有加法写、只有读的key
+func randomRWSets(r *rand.Rand, txNum, keyNum int) accesslist.RWSetList {
+	rwSets := make(accesslist.RWSetList, txNum)
+	for i := range rwSets {
+		var reads, writes, deltas []accesslist.AccessKey
+		for j := r.Intn(4); j > 0; j-- {
+			key := testKey(byte(r.Intn(keyNum)), byte(r.Intn(2)))
+			switch r.Intn(3) {
+			case 0:
+				reads = append(reads, key)
+			case 1:
+				writes = append(writes, key)
+			default:
+				deltas = append(deltas, key)
+			}
+		}
+		rwSets[i] = newTestRWSet(reads, writes, deltas)
+	}
+	return rwSets
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// 着色分轮与逐轮求MIS的对比: 分组时间、轮数和总代价
+func ColorTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) error {
+	colorfile, err := os.Create(("color.csv"))
+	if err != nil {
+		panic(err)
+	}
+	defer colorfile.Close()
+	colorWriter := csv.NewWriter(colorfile)
+	defer colorWriter.Flush()
+
+	err = colorWriter.Write([]string{"BlockNum", "TxNum", "misGroup", "colorGroup", "misRounds", "colorRounds", "misCost", "colorCost"})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("test start")
+	for i := 0; i < 500; i++ {
+		blockNum := blockNum + uint64(i)
+		fmt.Println("blockNum:", blockNum)
+		txs, _, rwAccessedBy := GetTxsAndPredicts(blockReader, ctx, dbTx, blockNum)
+		graph := GenerateUndiGraph(txs, rwAccessedBy)
+
+		misStart := time.Now()
+		misGroups := SolveMISInTurn(graph.Copy())
+		misTime := time.Since(misStart)
+		colorStart := time.Now()
+		colorGroups := SolveColorRounds(graph)
+		colorTime := time.Since(colorStart)
+
+		err = colorWriter.Write([]string{fmt.Sprint(blockNum), fmt.Sprint(len(txs)), fmt.Sprint(misTime.Microseconds()), fmt.Sprint(colorTime.Microseconds()), fmt.Sprint(len(misGroups)), fmt.Sprint(len(colorGroups)), fmt.Sprint(RoundsCost(graph, misGroups)), fmt.Sprint(RoundsCost(graph, colorGroups))})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return nil
+}
@@ -31,16 +31,41 @@ func PredictRWSets(blkCtx evmtypes.BlockContext, header *types.Header, dbTx kv.T
 // 从上一个区块的SNAPSHOT状态预测AccessLists，需要返回AccessedBy辅助建图
 func GetTxsAndPredicts(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) (types.Transactions, accesslist.RWSetList, *accesslist.RwAccessedBy) {
 	blk, header := GetBlockAndHeader(blockReader, ctx, dbTx, blockNum)
-	blkCtx := GetBlockContext(blockReader, blk, dbTx, header)
 	txs := blk.Transactions()
 
-	predictRwSets := make([]*accesslist.RWSet, txs.Len())
-	rwAccessedBy := accesslist.NewRwAccessedBy()
+	// 优先读缓存，没有命中再逐笔预测并写回缓存
+	predictor := Predictor
+	predictRwSets, ok := loadCachedRWSets(predictor.CacheKind(), blockNum, txs.Len())
+	if !ok {
+		blkCtx := GetBlockContext(blockReader, blk, dbTx, header)
+		predictRwSets = make([]*accesslist.RWSet, txs.Len())
+		switch predictor {
+		case PredictStatic:
+			ibs := GetState(params.MainnetChainConfig, dbTx, blockNum)
+			for i, tx := range txs {
+				predictRwSets[i] = PredictRWSetsFast(ibs, blkCtx, header, dbTx, tx, blockNum)
+			}
+		case PredictVerify:
+			ibs := GetState(params.MainnetChainConfig, dbTx, blockNum)
+			for i, tx := range txs {
+				predictRwSets[i] = PredictRWSetsVerify(ibs, blkCtx, header, dbTx, tx, blockNum)
+			}
+		default:
+			for i, tx := range txs {
+				predictRwSets[i] = PredictRWSets(blkCtx, header, dbTx, tx, blockNum)
+			}
+		}
+		storeCachedRWSets(predictor.CacheKind(), blockNum, predictRwSets)
+	}
 
-	for i, tx := range txs {
-		predictRwSets[i] = PredictRWSets(blkCtx, header, dbTx, tx, blockNum)
+	rwAccessedBy := accesslist.NewRwAccessedBy()
+	for i := range predictRwSets {
 		// 为了建图, 生成对应记录的AccessedBy
 		rwAccessedBy.Add(predictRwSets[i], uint(i))
+		// 两两比较时先用布隆过滤器排除
+		if predictRwSets[i] != nil {
+			predictRwSets[i].BuildBloom()
+		}
 	}
 	return txs, predictRwSets, rwAccessedBy
 }
@@ -195,7 +195,8 @@ func MISTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	misWriter := csv.NewWriter(misfile)
 	defer misWriter.Flush()
 	// 建图时间，分组时间，建图分组总时间，执行时间，总时间
-	err = misWriter.Write([]string{"BlockNum", "TxNum", "graph", "group", "graph+group", "execute", "total"})
+	// rounds为精确解+LinearTime得到的轮数，linearRounds为只用LinearTime的轮数(MISCompareLinear关闭时没有计算，留空)，后三列为各求解器处理的分量数
+	err = misWriter.Write([]string{"BlockNum", "TxNum", "graph", "group", "graph+group", "rounds", "linearRounds", "exactComponents", "linearComponents", "exactTimeouts", "execute", "total"})
 	if err != nil {
 		panic(err)
 	}
@@ -204,8 +205,12 @@ func MISTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	for i := 0; i < 500; i++ {
 		blockNum := blockNum + uint64(i)
 		fmt.Println("blockNum:", blockNum)
-		txsNum, graphTime, groupTime, graphGroupTime, executeTime, totalTime, _ := MISExec(blockReader, ctx, dbTx, blockNum)
-		err = misWriter.Write([]string{fmt.Sprint(blockNum), fmt.Sprint(txsNum), fmt.Sprint(graphTime), fmt.Sprint(groupTime), fmt.Sprint(graphGroupTime), fmt.Sprint(executeTime), fmt.Sprint(totalTime)})
+		txsNum, graphTime, groupTime, graphGroupTime, misStats, linearRounds, executeTime, totalTime, _ := MISExec(blockReader, ctx, dbTx, blockNum)
+		linearCell := ""
+		if MISCompareLinear {
+			linearCell = fmt.Sprint(linearRounds)
+		}
+		err = misWriter.Write([]string{fmt.Sprint(blockNum), fmt.Sprint(txsNum), fmt.Sprint(graphTime), fmt.Sprint(groupTime), fmt.Sprint(graphGroupTime), fmt.Sprint(misStats.Rounds), linearCell, fmt.Sprint(misStats.ExactComponents), fmt.Sprint(misStats.LinearComponents), fmt.Sprint(misStats.ExactTimeouts), fmt.Sprint(executeTime), fmt.Sprint(totalTime)})
 		if err != nil {
 			panic(err)
 		}
@@ -213,7 +218,7 @@ func MISTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	return nil
 }
 
-func MISExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) (int, int64, int64, int64, int64, int64, error) {
+func MISExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) (int, int64, int64, int64, MISStats, int, int64, int64, error) {
 	fmt.Println("MIS Execution")
 	block, header := GetBlockAndHeader(blockReader, ctx, dbTx, blockNum)
 	blkCtx := GetBlockContext(blockReader, block, dbTx, header)
@@ -222,7 +227,7 @@ func MISExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	txs, predictRwSets, rwAccessedBy := GetTxsAndPredicts(blockReader, ctx, dbTx, blockNum)
 	trueRwSets, err := TrueRWSets(blockReader, ctx, dbTx, blockNum)
 	if err != nil {
-		return 0, 0, 0, 0, 0, 0, err
+		return 0, 0, 0, 0, MISStats{}, 0, 0, 0, err
 	}
 
 	// 用预测的和真实的rwsets来预取数据构建并发statedb
@@ -235,17 +240,25 @@ func MISExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	var antsWG sync.WaitGroup
 
 	// st := time.Now()
-	// groups := utils.GenerateMISGroups(txs, predictRwSets)
+	// groups := utils.GenerateMISGroups(txs, rwAccessedBy, utils.MISMaxCount)
 	// fmt.Println("Generate TxGroups:", time.Since(st))
 	// 建图
 	graphStart := time.Now()
-	graph := GenerateUndiGraph(len(txs), rwAccessedBy)
+	graph := GenerateUndiGraph(txs, rwAccessedBy)
 	graphTime := time.Since(graphStart)
 	fmt.Println("graphtime:", graphTime)
 
+	// 只用LinearTime的轮数作为对比，不计入分组时间；没有开启时为0，MISTest写空单元格
+	linearRounds := 0
+	if MISCompareLinear {
+		linearStart := time.Now()
+		linearRounds = len(SolveMISInTurnLinear(graph.Copy()))
+		fmt.Println("linear grouptime:", time.Since(linearStart))
+	}
+
 	// 分组
 	groupstart := time.Now()
-	groups := SolveMISInTurn(graph)
+	groups, misStats := SolveMISInTurnWithStats(graph)
 	groupTime := time.Since(groupstart)
 
 	createGraphTime := time.Since(graphStart)
@@ -265,8 +278,8 @@ func MISExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	// 总时间
 	timeSum := time.Since(graphStart)
 
-	// 返回建图时间，分组时间，建图分组总时间，执行时间，多轮时间，总时间
-	return len(txs), int64(graphTime.Microseconds()), int64(groupTime.Microseconds()), int64(createGraphTime.Microseconds()), int64(PureExecutionCost.Microseconds()), int64(timeSum.Microseconds()), nil
+	// 返回建图时间，分组时间，建图分组总时间，各求解器的统计，只用LinearTime的轮数，执行时间，总时间
+	return len(txs), int64(graphTime.Microseconds()), int64(groupTime.Microseconds()), int64(createGraphTime.Microseconds()), misStats, linearRounds, int64(PureExecutionCost.Microseconds()), int64(timeSum.Microseconds()), nil
 }
 
 func DAGTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) error {
@@ -278,7 +291,8 @@ func DAGTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	dagWriter := csv.NewWriter(dagfile)
 	defer dagWriter.Flush()
 	// 建图时间，分组时间，建图分组总时间, 执行时间，总时间
-	err = dagWriter.Write([]string{"BlockNum", "TxNum", "graph", "group", "schedule", "scheduleCost", "execute", "total"})
+	// criticalPath为关键路径上的gas之和，是并行执行时间的下界
+	err = dagWriter.Write([]string{"BlockNum", "TxNum", "graph", "group", "schedule", "scheduleCost", "criticalPath", "execute", "total"})
 	if err != nil {
 		panic(err)
 	}
@@ -288,8 +302,12 @@ func DAGTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 		blockNum := blockNum + uint64(i)
 		fmt.Println("blockNum:", blockNum)
 		// testfunc.CCTest1(txs, predictRWSet, header, fakeChainCtx, state)
-		txsNum, graphTime, groupTime, scheduleTime, scheduleCost, executeTime, totalTime, _ := DAGExec(blockReader, ctx, dbTx, blockNum)
-		err = dagWriter.Write([]string{fmt.Sprint(blockNum), fmt.Sprint(txsNum), fmt.Sprint(graphTime), fmt.Sprint(groupTime), fmt.Sprint(scheduleTime), fmt.Sprint(scheduleCost), fmt.Sprint(executeTime), fmt.Sprint(totalTime)})
+		txsNum, graphTime, groupTime, scheduleTime, scheduleCost, criticalPath, executeTime, totalTime, err := DAGExec(blockReader, ctx, dbTx, blockNum)
+		if err != nil {
+			fmt.Println("blockNum:", blockNum, err)
+			continue
+		}
+		err = dagWriter.Write([]string{fmt.Sprint(blockNum), fmt.Sprint(txsNum), fmt.Sprint(graphTime), fmt.Sprint(groupTime), fmt.Sprint(scheduleTime), fmt.Sprint(scheduleCost), fmt.Sprint(criticalPath), fmt.Sprint(executeTime), fmt.Sprint(totalTime)})
 		if err != nil {
 			panic(err)
 		}
@@ -297,7 +315,7 @@ func DAGTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	return nil
 }
 
-func DAGExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) (int, int64, int64, int64, uint64, int64, int64, error) {
+func DAGExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) (int, int64, int64, int64, uint64, uint64, int64, int64, error) {
 	fmt.Println("DegreeZero Solution  Execution")
 	block, header := GetBlockAndHeader(blockReader, ctx, dbTx, blockNum)
 	blkCtx := GetBlockContext(blockReader, block, dbTx, header)
@@ -307,7 +325,7 @@ func DAGExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	txs, predictRwSets, rwAccessedBy := GetTxsAndPredicts(blockReader, ctx, dbTx, blockNum)
 	trueRwSets, err := TrueRWSets(blockReader, ctx, dbTx, blockNum)
 	if err != nil {
-		return 0, 0, 0, 0, 0, 0, 0, err
+		return 0, 0, 0, 0, 0, 0, 0, 0, err
 	}
 
 	// 用预测的和真实的rwsets来预取数据构建并发statedb
@@ -343,27 +361,26 @@ func DAGExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 
 	// 建图
 	graphStart := time.Now()
-	graph := GenerateDiGraph(len(txs), rwAccessedBy)
+	graph := GenerateDiGraph(txs, rwAccessedBy)
 	graphTime := time.Since(graphStart)
 	fmt.Println("graphtime:", graphTime)
 
 	// 分组
 	groupstart := time.Now()
-	groups := graph.GetTopo()
+	groups, err := graph.GetTopo()
+	if err != nil {
+		return 0, 0, 0, 0, 0, 0, 0, 0, err
+	}
 	groupTime := time.Since(groupstart)
 	scheduleTime := time.Since(graphStart)
 	fmt.Println("grouptime:", groupTime)
 
 	maxCost := uint64(0)
 	for i := 0; i < len(groups); i++ {
-		temp := txs[groups[i][0]].GetGas()
-		for j := 1; j < len(groups[i]); j++ {
-			if temp < txs[groups[i][j]].GetGas() {
-				temp = txs[groups[i][j]].GetGas()
-			}
-		}
-		maxCost += temp
+		maxCost += graph.MaxCost(groups[i])
 	}
+	// 关键路径不计入调度时间
+	criticalPath, _ := graph.CriticalPath()
 
 	PureExecutionCost := time.Duration(0)
 
@@ -377,8 +394,8 @@ func DAGExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv
 	// 总时间
 	timeSum := time.Since(graphStart)
 
-	// 返回建图时间，分组时间，建图分组总时间，执行时间，多轮时间，总时间
-	return len(txs), int64(graphTime.Microseconds()), int64(groupTime.Microseconds()), int64(scheduleTime.Microseconds()), maxCost, int64(PureExecutionCost.Microseconds()), int64(timeSum.Microseconds()), nil
+	// 返回建图时间，分组时间，建图分组总时间，按层估算的cost，关键路径cost，执行时间，总时间
+	return len(txs), int64(graphTime.Microseconds()), int64(groupTime.Microseconds()), int64(scheduleTime.Microseconds()), maxCost, criticalPath, int64(PureExecutionCost.Microseconds()), int64(timeSum.Microseconds()), nil
 }
 
 func GriaExec(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64, workerNum int) {
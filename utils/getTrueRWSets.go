@@ -14,6 +14,9 @@ import (
 
 func TrueRWSets(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) (accesslist.RWSetList, error) {
 	blk, header := GetBlockAndHeader(blockReader, ctx, dbTx, blockNum)
+	if lists, ok := loadCachedRWSets(TrueCacheKind, blockNum, blk.Transactions().Len()); ok {
+		return lists, nil
+	}
 	ibs := GetState(params.MainnetChainConfig, dbTx, blockNum)
 	fulldb := interactState.NewStateWithRwSets(ibs)
 	txs := blk.Transactions()
@@ -23,5 +26,6 @@ func TrueRWSets(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx
 			fmt.Println("In TRUERWSetsS, tx hash:", txs[i].Hash())
 		}
 	}
+	storeCachedRWSets(TrueCacheKind, blockNum, lists)
 	return lists, nil
 }
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	conflictgraph "erigonInteract/conflictGraph"
+	"fmt"
+	"os"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// 冲突图结构统计，区块范围与CCTest/DAGTest/MISTest相同，按BlockNum可以和对应的csv连接
+func GraphStatsTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) error {
+	statsfile, err := os.Create(("graphstats.csv"))
+	if err != nil {
+		panic(err)
+	}
+	defer statsfile.Close()
+	statsWriter := csv.NewWriter(statsfile)
+	defer statsWriter.Flush()
+
+	err = statsWriter.Write(append([]string{"BlockNum"}, conflictgraph.StatsHeader()...))
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("test start")
+	for i := 0; i < 500; i++ {
+		blockNum := blockNum + uint64(i)
+		fmt.Println("blockNum:", blockNum)
+		txs, _, rwAccessedBy := GetTxsAndPredicts(blockReader, ctx, dbTx, blockNum)
+		stats, err := conflictgraph.ComputeStats(GenerateUndiGraph(txs, rwAccessedBy), GenerateDiGraph(txs, rwAccessedBy))
+		if err != nil {
+			fmt.Println("blockNum:", blockNum, err)
+			continue
+		}
+		err = statsWriter.Write(append([]string{fmt.Sprint(blockNum)}, stats.Record()...))
+		if err != nil {
+			panic(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"erigonInteract/accesslist"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// HotKeyStat 一个(address, key)在区块范围内的累计统计，只统计产生了冲突边的区块
+type HotKeyStat struct {
+	Addr         common.Address
+	Hash         common.Hash
+	Blocks       int // 产生冲突的区块数
+	Readers      int // 读过该key的交易数
+	Writers      int // 写过该key的交易数，包括加法写
+	Edges        int // 该key贡献的冲突边数
+	MaxComponent int // 访问该key的交易所在连通分量的最大规模
+}
+
+// HotAddrStat 按账户聚合，SlotEdges按slot粒度统计冲突边，
+// AccountEdges把整个账户看作一个key, 两者差距越大说明slot粒度越重要
+type HotAddrStat struct {
+	Addr         common.Address
+	Blocks       int
+	SlotEdges    int
+	AccountEdges int
+}
+
+type HotKeyReport struct {
+	Keys  map[accesslist.AccessKey]*HotKeyStat
+	Addrs map[common.Address]*HotAddrStat
+}
+
+func NewHotKeyReport() *HotKeyReport {
+	return &HotKeyReport{
+		Keys:  make(map[accesslist.AccessKey]*HotKeyStat),
+		Addrs: make(map[common.Address]*HotAddrStat),
+	}
+}
+
+// 账户粒度下的读者/写者/加法写者
+type accountAccess struct {
+	readers, writers, deltas map[uint]struct{}
+}
+
+func newAccountAccess() *accountAccess {
+	return &accountAccess{
+		readers: make(map[uint]struct{}),
+		writers: make(map[uint]struct{}),
+		deltas:  make(map[uint]struct{}),
+	}
+}
+
+func sortedTxIds(txs map[uint]struct{}) []uint {
+	txIds := make([]uint, 0, len(txs))
+	for txId := range txs {
+		txIds = append(txIds, txId)
+	}
+	sort.Slice(txIds, func(i, j int) bool {
+		return txIds[i] < txIds[j]
+	})
+	return txIds
+}
+
+// AddBlock 统计一个区块的RwAccessedBy
+func (r *HotKeyReport) AddBlock(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) {
+	componentSize := make(map[uint]int)
+	for _, component := range GenerateVertexIdGroups(txs, rwAccessedBy) {
+		for _, txId := range component {
+			componentSize[txId] = len(component)
+		}
+	}
+
+	slotPairs := make(map[common.Address]map[accesslist.TxPair]struct{})
+	accounts := make(map[common.Address]*accountAccess)
+	for keyId := 0; keyId < rwAccessedBy.Keys.Len(); keyId++ {
+		key := rwAccessedBy.Keys.Key(uint32(keyId))
+		rTxs := rwAccessedBy.ReadBy.TxIds(uint32(keyId))
+		wTxs := rwAccessedBy.WriteBy.TxIds(uint32(keyId))
+		dTxs := rwAccessedBy.DeltaBy.TxIds(uint32(keyId))
+
+		account, ok := accounts[key.Addr]
+		if !ok {
+			account = newAccountAccess()
+			accounts[key.Addr] = account
+		}
+		for _, txId := range rTxs {
+			account.readers[txId] = struct{}{}
+		}
+		for _, txId := range wTxs {
+			account.writers[txId] = struct{}{}
+		}
+		for _, txId := range dTxs {
+			account.deltas[txId] = struct{}{}
+		}
+
+		pairs := rwAccessedBy.KeyConflicts(uint32(keyId))
+		if len(pairs) == 0 {
+			continue
+		}
+		stat, ok := r.Keys[key]
+		if !ok {
+			stat = &HotKeyStat{Addr: key.Addr, Hash: key.Hash}
+			r.Keys[key] = stat
+		}
+		stat.Blocks++
+		stat.Readers += len(rTxs)
+		stat.Writers += len(wTxs) + len(dTxs)
+		stat.Edges += len(pairs)
+		if _, ok := slotPairs[key.Addr]; !ok {
+			slotPairs[key.Addr] = make(map[accesslist.TxPair]struct{})
+		}
+		for pair := range pairs {
+			slotPairs[key.Addr][pair] = struct{}{}
+			stat.MaxComponent = max(stat.MaxComponent, componentSize[pair.From])
+		}
+	}
+
+	for addr, pairs := range slotPairs {
+		account := accounts[addr]
+		// 同时有普通写的交易按普通写算
+		for txId := range account.writers {
+			delete(account.deltas, txId)
+		}
+		accountPairs := accesslist.ConflictPairs(sortedTxIds(account.readers), sortedTxIds(account.writers), sortedTxIds(account.deltas))
+
+		stat, ok := r.Addrs[addr]
+		if !ok {
+			stat = &HotAddrStat{Addr: addr}
+			r.Addrs[addr] = stat
+		}
+		stat.Blocks++
+		stat.SlotEdges += len(pairs)
+		stat.AccountEdges += len(accountPairs)
+	}
+}
+
+// TopKeys 按贡献的边数从大到小排列，n<=0时返回全部
+func (r *HotKeyReport) TopKeys(n int) []*HotKeyStat {
+	stats := make([]*HotKeyStat, 0, len(r.Keys))
+	for _, stat := range r.Keys {
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Edges != stats[j].Edges {
+			return stats[i].Edges > stats[j].Edges
+		}
+		if c := bytes.Compare(stats[i].Addr[:], stats[j].Addr[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(stats[i].Hash[:], stats[j].Hash[:]) < 0
+	})
+	if n > 0 && n < len(stats) {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+func (r *HotKeyReport) TopAddrs(n int) []*HotAddrStat {
+	stats := make([]*HotAddrStat, 0, len(r.Addrs))
+	for _, stat := range r.Addrs {
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].SlotEdges != stats[j].SlotEdges {
+			return stats[i].SlotEdges > stats[j].SlotEdges
+		}
+		return bytes.Compare(stats[i].Addr[:], stats[j].Addr[:]) < 0
+	})
+	if n > 0 && n < len(stats) {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+func (r *HotKeyReport) WriteCSV(keyPath, addrPath string) error {
+	keyfile, err := os.Create(keyPath)
+	if err != nil {
+		return err
+	}
+	defer keyfile.Close()
+	keyWriter := csv.NewWriter(keyfile)
+	err = keyWriter.Write([]string{"Address", "Key", "Blocks", "Readers", "Writers", "Edges", "MaxComponent"})
+	if err != nil {
+		return err
+	}
+	for _, stat := range r.TopKeys(0) {
+		err = keyWriter.Write([]string{stat.Addr.Hex(), accesslist.DecodeHash(stat.Hash), fmt.Sprint(stat.Blocks), fmt.Sprint(stat.Readers), fmt.Sprint(stat.Writers), fmt.Sprint(stat.Edges), fmt.Sprint(stat.MaxComponent)})
+		if err != nil {
+			return err
+		}
+	}
+	keyWriter.Flush()
+	if err = keyWriter.Error(); err != nil {
+		return err
+	}
+
+	addrfile, err := os.Create(addrPath)
+	if err != nil {
+		return err
+	}
+	defer addrfile.Close()
+	addrWriter := csv.NewWriter(addrfile)
+	err = addrWriter.Write([]string{"Address", "Blocks", "SlotEdges", "AccountEdges"})
+	if err != nil {
+		return err
+	}
+	for _, stat := range r.TopAddrs(0) {
+		err = addrWriter.Write([]string{stat.Addr.Hex(), fmt.Sprint(stat.Blocks), fmt.Sprint(stat.SlotEdges), fmt.Sprint(stat.AccountEdges)})
+		if err != nil {
+			return err
+		}
+	}
+	addrWriter.Flush()
+	return addrWriter.Error()
+}
+
+func (r *HotKeyReport) PrintSummary(n int) {
+	fmt.Println("Top", n, "hot keys:")
+	for i, stat := range r.TopKeys(n) {
+		fmt.Printf("%2d. %s %s edges=%d readers=%d writers=%d blocks=%d maxComponent=%d\n",
+			i+1, stat.Addr.Hex(), accesslist.DecodeHash(stat.Hash), stat.Edges, stat.Readers, stat.Writers, stat.Blocks, stat.MaxComponent)
+	}
+	fmt.Println("Top", n, "hot accounts:")
+	for i, stat := range r.TopAddrs(n) {
+		fmt.Printf("%2d. %s slotEdges=%d accountEdges=%d blocks=%d\n",
+			i+1, stat.Addr.Hex(), stat.SlotEdges, stat.AccountEdges, stat.Blocks)
+	}
+}
+
+// 热点key统计，结果写入hotkey.csv和hotaddr.csv
+func HotKeyTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64, topN int) error {
+	report := NewHotKeyReport()
+	fmt.Println("test start")
+	for i := 0; i < 500; i++ {
+		blockNum := blockNum + uint64(i)
+		fmt.Println("blockNum:", blockNum)
+		txs, _, rwAccessedBy := GetTxsAndPredicts(blockReader, ctx, dbTx, blockNum)
+		report.AddBlock(txs, rwAccessedBy)
+	}
+	if err := report.WriteCSV("hotkey.csv", "hotaddr.csv"); err != nil {
+		return err
+	}
+	report.PrintSummary(topN)
+	return nil
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"encoding/csv"
+	"erigonInteract/accesslist"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type keys = []accesslist.AccessKey
+
+func newHotKeyBlock() accesslist.RWSetList {
+	a, b, c, d, e := testKey(1, 1), testKey(1, 2), testKey(2, 0), testKey(4, 1), testKey(3, 1)
+	return accesslist.RWSetList{
+		// a: 1个写者2个读者，2条边
+		newTestRWSet(nil, keys{a}, nil),
+		newTestRWSet(keys{a}, nil, nil),
+		newTestRWSet(keys{a}, nil, nil),
+		// b: 两个加法写者，没有边；c: 加法写和读，1条边
+		newTestRWSet(nil, nil, keys{b, c}),
+		newTestRWSet(nil, nil, keys{b}),
+		newTestRWSet(keys{c}, nil, nil),
+		// d: 3个写者两两冲突，3条边
+		newTestRWSet(nil, keys{d}, nil),
+		newTestRWSet(nil, keys{d}, nil),
+		newTestRWSet(nil, keys{d}, nil),
+		// e: 与a的边数相同，地址更大，排在a后面
+		newTestRWSet(nil, keys{e}, nil),
+		newTestRWSet(keys{e}, nil, nil),
+		newTestRWSet(keys{e}, nil, nil),
+	}
+}
+
+func TestHotKeyReport(t *testing.T) {
+	report := NewHotKeyReport()
+	txs, rwAccessedBy := newTestBlock(newHotKeyBlock())
+	report.AddBlock(txs, rwAccessedBy)
+	txs, rwAccessedBy = newTestBlock(newHotKeyBlock())
+	report.AddBlock(txs, rwAccessedBy)
+
+	expected := []struct {
+		key                                           accesslist.AccessKey
+		blocks, readers, writers, edges, maxComponent int
+	}{
+		{testKey(4, 1), 2, 0, 6, 6, 3},
+		{testKey(1, 1), 2, 4, 2, 4, 3},
+		{testKey(3, 1), 2, 4, 2, 4, 3},
+		{testKey(2, 0), 2, 2, 2, 2, 2},
+	}
+	top := report.TopKeys(0)
+	if len(top) != len(expected) {
+		t.Fatalf("expected %d hot keys, got %d", len(expected), len(top))
+	}
+	for i, want := range expected {
+		got := top[i]
+		if got.Addr != want.key.Addr || got.Hash != want.key.Hash {
+			t.Fatalf("rank %d: got %s %s", i, got.Addr.Hex(), got.Hash.Hex())
+		}
+		if got.Blocks != want.blocks || got.Readers != want.readers || got.Writers != want.writers ||
+			got.Edges != want.edges || got.MaxComponent != want.maxComponent {
+			t.Fatalf("rank %d: unexpected stat %+v", i, *got)
+		}
+	}
+	if top := report.TopKeys(2); len(top) != 2 || top[1].Addr != testKey(1, 1).Addr {
+		t.Fatal("TopKeys(2) should keep the first two keys")
+	}
+
+	// 地址1: slot粒度只有a的2条边；账户粒度下每个区块8条边
+	addr := report.Addrs[testKey(1, 1).Addr]
+	if addr == nil || addr.Blocks != 2 || addr.SlotEdges != 4 || addr.AccountEdges != 16 {
+		t.Fatalf("unexpected account stat %+v", addr)
+	}
+	if _, ok := report.Addrs[testKey(5, 0).Addr]; ok {
+		t.Fatal("untouched address reported")
+	}
+}
+
+func TestHotKeyReportCSV(t *testing.T) {
+	report := NewHotKeyReport()
+	txs, rwAccessedBy := newTestBlock(newHotKeyBlock())
+	report.AddBlock(txs, rwAccessedBy)
+
+	dir := t.TempDir()
+	keyPath, addrPath := filepath.Join(dir, "hotkey.csv"), filepath.Join(dir, "hotaddr.csv")
+	if err := report.WriteCSV(keyPath, addrPath); err != nil {
+		t.Fatal(err)
+	}
+	for path, rows := range map[string]int{keyPath: 1 + 4, addrPath: 1 + 4} {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(file).ReadAll()
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != rows {
+			t.Fatalf("%s: expected %d rows, got %d", path, rows, len(records))
+		}
+	}
+}
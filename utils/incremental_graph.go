@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"erigonInteract/accesslist"
+	conflictgraph "erigonInteract/conflictGraph"
+)
+
+// IncrementalGraph 交易逐笔到达时在线维护的冲突图，
+// 同时维护RwAccessedBy、无向图、有向图和连通分量，不需要每次从头建图
+type IncrementalGraph struct {
+	RwAccessedBy *accesslist.RwAccessedBy
+	Undirected   *conflictgraph.UndirectedGraph
+	Directed     *conflictgraph.DirectedGraph
+	Components   *conflictgraph.UnionFind
+}
+
+func NewIncrementalGraph() *IncrementalGraph {
+	return &IncrementalGraph{
+		RwAccessedBy: accesslist.NewRwAccessedBy(),
+		Undirected:   conflictgraph.NewUndirectedGraph(),
+		Directed:     conflictgraph.NewDirectedGraph(),
+		Components:   conflictgraph.NewUnionFind(),
+	}
+}
+
+// AddTx 加入一笔代价为0的交易
+func (g *IncrementalGraph) AddTx(id uint, rwSet *accesslist.RWSet) {
+	g.AddTxWithCost(id, rwSet, 0)
+}
+
+// AddTxWithCost 加入一笔交易并连上它与已有交易之间的冲突边，
+// 边的方向和类型按交易id的先后确定，与GenerateUndiGraph/GenerateDiGraph的结果一致
+func (g *IncrementalGraph) AddTxWithCost(id uint, rwSet *accesslist.RWSet, cost uint64) {
+	g.Undirected.AddVertexWithCost(id, cost)
+	g.Directed.AddVertexWithCost(id, cost)
+	g.Components.Add(id)
+	if rwSet == nil {
+		return
+	}
+	// Add之后rwSet才有key id，访问者列表里也包含了id自己
+	g.RwAccessedBy.Add(rwSet, id)
+
+	readBy := g.RwAccessedBy.ReadBy
+	writeBy := g.RwAccessedBy.WriteBy
+	deltaBy := g.RwAccessedBy.DeltaBy
+	for _, keyId := range rwSet.ReadIds {
+		for _, wTx := range writeBy.TxIds(keyId) {
+			g.addEdge(wTx, id, readWriteType(wTx, id))
+		}
+		for _, dTx := range deltaBy.TxIds(keyId) {
+			g.addEdge(dTx, id, readWriteType(dTx, id))
+		}
+	}
+	for _, keyId := range rwSet.WriteIds {
+		for _, wTx := range writeBy.TxIds(keyId) {
+			g.addEdge(wTx, id, conflictgraph.EdgeType(accesslist.WAW))
+		}
+		for _, dTx := range deltaBy.TxIds(keyId) {
+			g.addEdge(dTx, id, conflictgraph.EdgeType(accesslist.WAW))
+		}
+		for _, rTx := range readBy.TxIds(keyId) {
+			g.addEdge(rTx, id, readWriteType(id, rTx))
+		}
+	}
+	// 加法写之间不冲突
+	for _, keyId := range rwSet.DeltaIds {
+		for _, wTx := range writeBy.TxIds(keyId) {
+			g.addEdge(wTx, id, conflictgraph.EdgeType(accesslist.WAW))
+		}
+		for _, rTx := range readBy.TxIds(keyId) {
+			g.addEdge(rTx, id, readWriteType(id, rTx))
+		}
+	}
+}
+
+func (g *IncrementalGraph) addEdge(other, id uint, t conflictgraph.EdgeType) {
+	if other == id {
+		return
+	}
+	g.Undirected.AddTypedEdge(other, id, t)
+	g.Directed.AddTypedEdge(min(other, id), max(other, id), t)
+	g.Components.Union(other, id)
+}
+
+// VertexIdGroups 当前的连通分量，直接取自并查集
+func (g *IncrementalGraph) VertexIdGroups() [][]uint {
+	return g.Components.Components()
+}
+
+// TopoGroups GetTopo会修改入度，所以在有向图的副本上分层
+func (g *IncrementalGraph) TopoGroups() ([][]uint, error) {
+	return g.Directed.Copy().GetTopo()
+}
+
+// MISGroups SolveMISInTurn会删除顶点，同样在副本上求解
+func (g *IncrementalGraph) MISGroups() [][]uint {
+	return SolveMISInTurn(g.Undirected.Copy())
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bytes"
+	"erigonInteract/accesslist"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// 乱序加入交易后，增量图应与从头建的稠密图完全相同
+func checkIncremental(t *testing.T, rwSets accesslist.RWSetList, order []int) {
+	txs, rwAccessedBy := newTestBlock(rwSets)
+	incGraph := NewIncrementalGraph()
+	for _, i := range order {
+		incGraph.AddTxWithCost(uint(i), rwSets[i], txs[i].GetGas())
+	}
+
+	var got, want bytes.Buffer
+	incGraph.Undirected.WriteCanonical(&got)
+	GenerateUndiGraph(txs, rwAccessedBy).WriteCanonical(&want)
+	if got.String() != want.String() {
+		t.Fatalf("order %v: undirected graph differs\ngot:\n%s\nwant:\n%s", order, got.String(), want.String())
+	}
+	got.Reset()
+	want.Reset()
+	incGraph.Directed.WriteCanonical(&got)
+	GenerateDiGraph(txs, rwAccessedBy).WriteCanonical(&want)
+	if got.String() != want.String() {
+		t.Fatalf("order %v: directed graph differs\ngot:\n%s\nwant:\n%s", order, got.String(), want.String())
+	}
+	components := canonicalGroups(incGraph.VertexIdGroups())
+	expected := canonicalGroups(GenerateUndiGraph(txs, rwAccessedBy).GetConnectedComponents())
+	if !reflect.DeepEqual(components, expected) {
+		t.Fatalf("order %v: components %v, want %v", order, components, expected)
+	}
+	// apexPlusExec用它代替GenerateVertexIdGroups，分组和顺序都要一样
+	if groups, want := incGraph.VertexIdGroups(), GenerateVertexIdGroups(txs, rwAccessedBy); !reflect.DeepEqual(groups, want) {
+		t.Fatalf("order %v: vertex id groups %v, want %v", order, groups, want)
+	}
+}
+
+func TestIncrementalGraph(t *testing.T) {
+	a, b, c := testKey(1, 1), testKey(2, 0), testKey(3, 1)
+	rwSets := accesslist.RWSetList{
+		newTestRWSet(keys{a}, nil, keys{b}),
+		newTestRWSet(nil, keys{a}, nil),
+		newTestRWSet(nil, nil, keys{b}),
+		newTestRWSet(keys{b}, nil, nil),
+		// c只有加法写，不产生边
+		newTestRWSet(nil, nil, keys{c}),
+		newTestRWSet(nil, nil, keys{c}),
+		// 同一笔交易读写同一个key，不连自环
+		newTestRWSet(keys{a}, keys{a}, nil),
+		nil,
+	}
+	checkIncremental(t, rwSets, []int{0, 1, 2, 3, 4, 5, 6, 7})
+	checkIncremental(t, rwSets, []int{7, 6, 3, 1, 5, 0, 4, 2})
+
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 200; iter++ {
+		rwSets := randomRWSets(r, 1+r.Intn(30), 1+r.Intn(8))
+		checkIncremental(t, rwSets, r.Perm(len(rwSets)))
+	}
+}
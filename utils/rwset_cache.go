@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"erigonInteract/accesslist"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RWSetCacheDir 存放预测/真实读写集缓存的目录，置空则关闭缓存
+var RWSetCacheDir = "rwsetcache"
+
+// EVM预测沿用原来的predict缓存，静态预测单独缓存
+const (
+	PredictCacheKind       = "predict"
+	StaticPredictCacheKind = "predict_static"
+	TrueCacheKind          = "true"
+)
+
+// 每个区块一个文件，如 rwsetcache/predict_18999500.bin
+func rwSetCachePath(kind string, blockNum uint64) string {
+	return filepath.Join(RWSetCacheDir, fmt.Sprintf("%s_%d.bin", kind, blockNum))
+}
+
+// LoadRWSetList 从缓存中读取某个区块的读写集，缓存不存在时返回os.ErrNotExist
+func LoadRWSetList(kind string, blockNum uint64) (accesslist.RWSetList, error) {
+	if RWSetCacheDir == "" {
+		return nil, os.ErrNotExist
+	}
+	file, err := os.Open(rwSetCachePath(kind, blockNum))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return accesslist.DecodeRWSetList(file)
+}
+
+// StoreRWSetList 先写临时文件再rename，避免中断时留下半个缓存文件
+func StoreRWSetList(kind string, blockNum uint64, list accesslist.RWSetList) error {
+	if RWSetCacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(RWSetCacheDir, 0755); err != nil {
+		return err
+	}
+	path := rwSetCachePath(kind, blockNum)
+	file, err := os.CreateTemp(RWSetCacheDir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if err = list.EncodeTo(file); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// 读缓存，txNum用于校验缓存和区块是否对得上；缓存不可用时返回false
+func loadCachedRWSets(kind string, blockNum uint64, txNum int) (accesslist.RWSetList, bool) {
+	list, err := LoadRWSetList(kind, blockNum)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Println("Ignore rwset cache:", rwSetCachePath(kind, blockNum), err)
+		}
+		return nil, false
+	}
+	if len(list) != txNum {
+		fmt.Println("Ignore rwset cache:", rwSetCachePath(kind, blockNum), "txNum mismatch", len(list), txNum)
+		return nil, false
+	}
+	return list, true
+}
+
+func storeCachedRWSets(kind string, blockNum uint64, list accesslist.RWSetList) {
+	if err := StoreRWSetList(kind, blockNum, list); err != nil {
+		fmt.Println("Failed to store rwset cache:", rwSetCachePath(kind, blockNum), err)
+	}
+}
@@ -1,13 +1,19 @@
 package utils
 
 import (
+	"context"
 	"erigonInteract/accesslist"
 	conflictgraph "erigonInteract/conflictGraph"
 	"erigonInteract/mis"
 	"erigonInteract/oldmis"
+	"fmt"
+	"io"
 	"sort"
+	"time"
 
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
 )
 
 func GenerateCCGroups(vertexGroup [][]uint, txs types.Transactions, predictRWSets accesslist.RWSetList) ([]types.Transactions, []accesslist.RWSetList) {
@@ -26,19 +32,93 @@ func GenerateCCGroups(vertexGroup [][]uint, txs types.Transactions, predictRWSet
 	return txsGroup, RWSetsGroup
 }
 
-// solveMISInTurn an approximation algorithm to solve MIS problem
+// 小于等于MISExactThreshold个顶点的连通分量用精确解，每个分量最多用MISExactBudget
+var (
+	MISExactThreshold = 64
+	MISExactBudget    = 10 * time.Millisecond
+)
+
+// MISCompareLinear 为true时MISExec额外只用LinearTime分组一次，记录轮数用于对比，耗时单独打印
+var MISCompareLinear = false
+
+// MISStats SolveMISInTurnWithStats中各求解器处理的连通分量数(累计所有轮次)
+type MISStats struct {
+	Rounds           int
+	ExactComponents  int
+	LinearComponents int
+	ExactTimeouts    int // 精确求解超时，用的是当时最好的解
+}
+
+// SolveMISInTurn 每一轮按连通分量分别求独立集，小分量用分支定界的精确解，大分量用LinearTime
 func SolveMISInTurn(graph *conflictgraph.UndirectedGraph) [][]uint {
+	ans, _ := SolveMISInTurnWithStats(graph)
+	return ans
+}
+
+func SolveMISInTurnWithStats(graph *conflictgraph.UndirectedGraph) ([][]uint, MISStats) {
+	var stats MISStats
+	ans := solveComponentsInTurn(graph, func(sub *conflictgraph.UndirectedGraph) []uint {
+		if len(sub.Vertices) <= MISExactThreshold {
+			res, optimal := mis.ExactMIS(sub, MISExactBudget)
+			stats.ExactComponents++
+			if !optimal {
+				stats.ExactTimeouts++
+			}
+			return res
+		}
+		MisSolution := mis.NewDeterministicSolution(sub)
+		MisSolution.Solve()
+		stats.LinearComponents++
+		return MisSolution.Result()
+	})
+	stats.Rounds = len(ans)
+	return ans, stats
+}
+
+// SolveWeightedMISInTurn 每一轮求按weight加权的最大权独立集，重交易集中在前面的轮次，
+// 每轮的代价(最重的交易)更均衡；小分量用精确解，大分量用贪心
+func SolveWeightedMISInTurn(graph *conflictgraph.UndirectedGraph, weight mis.VertexWeight) [][]uint {
+	return solveComponentsInTurn(graph, func(sub *conflictgraph.UndirectedGraph) []uint {
+		if len(sub.Vertices) <= MISExactThreshold {
+			res, _ := mis.ExactWeightedMIS(sub, weight, MISExactBudget)
+			return res
+		}
+		return mis.GreedyWeightedMIS(sub, weight)
+	})
+}
+
+// solveComponentsInTurn 逐轮删除独立集直到图为空，每一轮对各连通分量分别调用solve，
+// 每轮内按TxId升序；会删除graph中的顶点
+func solveComponentsInTurn(graph *conflictgraph.UndirectedGraph, solve func(sub *conflictgraph.UndirectedGraph) []uint) [][]uint {
+	ans := make([][]uint, 0)
+	for len(graph.Vertices) > 0 {
+		round := make([]uint, 0)
+		for _, component := range graph.GetConnectedComponents() {
+			round = append(round, solve(graph.Subgraph(component))...)
+		}
+		sort.Slice(round, func(i, j int) bool {
+			return round[i] < round[j]
+		})
+		for _, v := range round {
+			graph.RemoveVertex(v)
+		}
+		ans = append(ans, round)
+	}
+	return ans
+}
+
+// solveMISInTurn an approximation algorithm to solve MIS problem
+// 只用确定性的LinearTime，每一轮内按TxId升序，同一个图每次得到的轮次都相同
+func SolveMISInTurnLinear(graph *conflictgraph.UndirectedGraph) [][]uint {
 	ans := make([][]uint, 0)
 	for {
 		graphCpy := graph.Copy()
-		MisSolution := mis.NewSolution(graphCpy)
+		MisSolution := mis.NewDeterministicSolution(graphCpy)
 		MisSolution.Solve()
 
-		ansSlice := MisSolution.IndependentSet.ToSlice()
-		ansSliceUint := make([]uint, len(ansSlice))
-		for i, v := range ansSlice {
-			ansSliceUint[i] = v.(uint)
-			graph.RemoveVertex(v.(uint))
+		ansSliceUint := MisSolution.Result()
+		for _, v := range ansSliceUint {
+			graph.RemoveVertex(v)
 		}
 		ans = append(ans, ansSliceUint)
 		if len(graph.Vertices) == 0 {
@@ -48,34 +128,57 @@ func SolveMISInTurn(graph *conflictgraph.UndirectedGraph) [][]uint {
 	return ans
 }
 
-func GenerateUndiGraph(vertexNum int, rwAccessedBy *accesslist.RwAccessedBy) *conflictgraph.UndirectedGraph {
+// 写者与读者之间的冲突类型，以交易顺序为准
+func readWriteType(writer, reader uint) conflictgraph.EdgeType {
+	if writer < reader {
+		return conflictgraph.EdgeType(accesslist.RAW)
+	}
+	return conflictgraph.EdgeType(accesslist.WAR)
+}
+
+func GenerateUndiGraph(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) *conflictgraph.UndirectedGraph {
 	undiConfGraph := conflictgraph.NewUndirectedGraph()
 	readBy := rwAccessedBy.ReadBy
 	writeBy := rwAccessedBy.WriteBy
+	deltaBy := rwAccessedBy.DeltaBy
 
-	// 先添加所有的点
-	for i := 0; i < vertexNum; i++ {
-		undiConfGraph.AddVertex(uint(i))
+	// 先添加所有的点, 以gas作为代价
+	for i, tx := range txs {
+		undiConfGraph.AddVertexWithCost(uint(i), tx.GetGas())
 	}
 
-	for addr, wAccess := range writeBy {
-		for hash := range wAccess {
-			wTxs := writeBy.TxIds(addr, hash)
-			rTxs := readBy.TxIds(addr, hash)
-			// 先添加所有写写冲突
-			for i := 0; i < len(wTxs); i++ {
-				for j := i + 1; j < len(wTxs); j++ {
-					undiConfGraph.AddEdge(wTxs[i], wTxs[j])
+	for keyId := 0; keyId < rwAccessedBy.Keys.Len(); keyId++ {
+		wTxs := writeBy.TxIds(uint32(keyId))
+		rTxs := readBy.TxIds(uint32(keyId))
+		dTxs := deltaBy.TxIds(uint32(keyId))
+		// 先添加所有写写冲突
+		for i := 0; i < len(wTxs); i++ {
+			for j := i + 1; j < len(wTxs); j++ {
+				undiConfGraph.AddTypedEdge(wTxs[i], wTxs[j], conflictgraph.EdgeType(accesslist.WAW))
+			}
+		}
+		// 再添加所有读写冲突, 加法写也要和读、普通写冲突
+		for _, wTx := range wTxs {
+			for _, rTx := range rTxs {
+				if rTx == wTx {
+					continue
 				}
+				undiConfGraph.AddTypedEdge(rTx, wTx, readWriteType(wTx, rTx))
 			}
-			// 再添加所有读写冲突
+			for _, dTx := range dTxs {
+				if dTx == wTx {
+					continue
+				}
+				undiConfGraph.AddTypedEdge(dTx, wTx, conflictgraph.EdgeType(accesslist.WAW))
+			}
+		}
+		// 加法写之间不冲突
+		for _, dTx := range dTxs {
 			for _, rTx := range rTxs {
-				for _, wTx := range wTxs {
-					if rTx == wTx {
-						continue
-					}
-					undiConfGraph.AddEdge(rTx, wTx)
+				if rTx == dTx {
+					continue
 				}
+				undiConfGraph.AddTypedEdge(rTx, dTx, readWriteType(dTx, rTx))
 			}
 		}
 	}
@@ -83,58 +186,191 @@ func GenerateUndiGraph(vertexNum int, rwAccessedBy *accesslist.RwAccessedBy) *co
 	return undiConfGraph
 }
 
+// GenerateUndiGraphWithConflicts 额外返回每条边由哪些key、哪种冲突产生，用于排查过大的连通分量
+func GenerateUndiGraphWithConflicts(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) (*conflictgraph.UndirectedGraph, map[accesslist.TxPair][]accesslist.Conflict) {
+	return GenerateUndiGraph(txs, rwAccessedBy), rwAccessedBy.ExplainAll()
+}
+
 func GenerateVertexIdGroups(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) [][]uint {
-	undiConfGraph := GenerateUndiGraph(len(txs), rwAccessedBy)
-	groups := undiConfGraph.GetConnectedComponents()
-	return groups
+	return GenerateUnionFindGroups(txs, rwAccessedBy)
+}
+
+// GenerateUnionFindGroups 不建边，直接用并查集从RwAccessedBy求连通分量，结果与无向图的连通分量相同
+func GenerateUnionFindGroups(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) [][]uint {
+	uf := conflictgraph.NewUnionFind()
+	for i := range txs {
+		uf.Add(uint(i))
+	}
+	union := func(root uint, txIds []uint) {
+		for _, txId := range txIds {
+			uf.Union(root, txId)
+		}
+	}
+
+	for keyId := 0; keyId < rwAccessedBy.Keys.Len(); keyId++ {
+		wTxs := rwAccessedBy.WriteBy.TxIds(uint32(keyId))
+		rTxs := rwAccessedBy.ReadBy.TxIds(uint32(keyId))
+		dTxs := rwAccessedBy.DeltaBy.TxIds(uint32(keyId))
+		if len(wTxs) > 0 {
+			// 读者、加法写者都和第一个写者冲突，写者之间两两冲突
+			union(wTxs[0], wTxs)
+			union(wTxs[0], rTxs)
+			union(wTxs[0], dTxs)
+		} else if len(dTxs) > 0 && len(rTxs) > 0 {
+			// 只有加法写和读时，除非是同一笔交易自己读自己加，否则所有访问者连通
+			if len(dTxs) == 1 && len(rTxs) == 1 && dTxs[0] == rTxs[0] {
+				continue
+			}
+			union(dTxs[0], dTxs)
+			union(dTxs[0], rTxs)
+		}
+	}
+	return uf.Components()
 }
 
-func GenerateMISGroups(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) [][]uint {
-	undiGraph := GenerateUndiGraph(len(txs), rwAccessedBy)
+// MISMode MIS分组每一轮的目标
+type MISMode int
+
+const (
+	MISMaxCount MISMode = iota // 每轮的交易数最多
+	MISMaxGas                  // 每轮的gas之和最大
+)
+
+func GenerateMISGroups(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy, mode MISMode) [][]uint {
+	undiGraph := GenerateUndiGraph(txs, rwAccessedBy)
+	if mode == MISMaxGas {
+		return SolveWeightedMISInTurn(undiGraph, mis.GasWeight)
+	}
 	return SolveMISInTurn(undiGraph)
 }
 
+func GenerateColorGroups(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) [][]uint {
+	return SolveColorRounds(GenerateUndiGraph(txs, rwAccessedBy))
+}
+
+// SolveColorRounds 用图着色分轮，不需要像SolveMISInTurn那样每轮复制图、重新求解；
+// DSatur和Welsh-Powell中取总代价(每轮最重的交易之和)较小的，不修改图
+func SolveColorRounds(graph *conflictgraph.UndirectedGraph) [][]uint {
+	dsatur, welshPowell := graph.DSatur(), graph.WelshPowell()
+	if RoundsCost(graph, welshPowell) < RoundsCost(graph, dsatur) {
+		return welshPowell
+	}
+	return dsatur
+}
+
+// RoundsCost 每一轮的代价为其中最重的交易，总代价为各轮之和
+func RoundsCost(graph *conflictgraph.UndirectedGraph, groups [][]uint) uint64 {
+	var cost uint64
+	for _, group := range groups {
+		cost += graph.MaxCost(group)
+	}
+	return cost
+}
+
+// SumGas 一组交易的gas之和，即这组交易串行执行的代价，与图中顶点的代价一致
+func SumGas(txs types.Transactions, group []uint) uint64 {
+	var sum uint64
+	for _, id := range group {
+		sum += txs[id].GetGas()
+	}
+	return sum
+}
+
 func GenerateOldMISGroups(txs types.Transactions, predictRWSets accesslist.RWSetList) [][]uint {
 	undiGraph := oldmis.OldGenerateUndiGraph(txs, predictRWSets)
 	return oldmis.OldSolveMISInTurn(undiGraph)
 }
 
-func GenerateDiGraph(vertexNum int, rwAccessedBy *accesslist.RwAccessedBy) *conflictgraph.DirectedGraph {
+func GenerateDiGraph(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) *conflictgraph.DirectedGraph {
 	Graph := conflictgraph.NewDirectedGraph()
 	readBy := rwAccessedBy.ReadBy
 	writeBy := rwAccessedBy.WriteBy
+	deltaBy := rwAccessedBy.DeltaBy
 
-	// 先添加所有的点
-	for i := 0; i < vertexNum; i++ {
-		Graph.AddVertex(uint(i))
+	// 先添加所有的点, 以gas作为代价
+	for i, tx := range txs {
+		Graph.AddVertexWithCost(uint(i), tx.GetGas())
 	}
 
-	for addr, wAccess := range writeBy {
-		for hash := range wAccess {
-			wTxs := writeBy.TxIds(addr, hash)
-			rTxs := readBy.TxIds(addr, hash)
-			// 先添加所有写写冲突，返回的wTxs和rTxs是有序的
-			for i := 0; i < len(wTxs); i++ {
-				for j := i + 1; j < len(wTxs); j++ {
-					Graph.AddEdge(wTxs[i], wTxs[j])
+	for keyId := 0; keyId < rwAccessedBy.Keys.Len(); keyId++ {
+		wTxs := writeBy.TxIds(uint32(keyId))
+		rTxs := readBy.TxIds(uint32(keyId))
+		dTxs := deltaBy.TxIds(uint32(keyId))
+		// 先添加所有写写冲突，返回的wTxs、rTxs和dTxs都是有序的
+		for i := 0; i < len(wTxs); i++ {
+			for j := i + 1; j < len(wTxs); j++ {
+				Graph.AddTypedEdge(wTxs[i], wTxs[j], conflictgraph.EdgeType(accesslist.WAW))
+			}
+		}
+		// 再添加所有读写冲突，不过有方向
+		for _, wTx := range wTxs {
+			for _, rTx := range rTxs {
+				if rTx == wTx {
+					continue
 				}
+				Graph.AddTypedEdge(min(rTx, wTx), max(rTx, wTx), readWriteType(wTx, rTx))
 			}
-			// 再添加所有读写冲突，不过有方向
+			for _, dTx := range dTxs {
+				if dTx == wTx {
+					continue
+				}
+				Graph.AddTypedEdge(min(dTx, wTx), max(dTx, wTx), conflictgraph.EdgeType(accesslist.WAW))
+			}
+		}
+		// 加法写之间不冲突
+		for _, dTx := range dTxs {
 			for _, rTx := range rTxs {
-				for _, wTx := range wTxs {
-					if rTx == wTx {
-						continue
-					}
-					Graph.AddEdge(min(rTx, wTx), max(rTx, wTx))
+				if rTx == dTx {
+					continue
 				}
+				Graph.AddTypedEdge(min(rTx, dTx), max(rTx, dTx), readWriteType(dTx, rTx))
 			}
 		}
-
 	}
 	return Graph
 }
 
-func GenerateTopoGroups(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) [][]uint {
-	graph := GenerateDiGraph(len(txs), rwAccessedBy)
+// GenerateDiGraphWithConflicts 有向边source -> destination对应TxPair{From: source, To: destination}
+func GenerateDiGraphWithConflicts(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) (*conflictgraph.DirectedGraph, map[accesslist.TxPair][]accesslist.Conflict) {
+	return GenerateDiGraph(txs, rwAccessedBy), rwAccessedBy.ExplainAll()
+}
+
+func GenerateTopoGroups(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) ([][]uint, error) {
+	graph := GenerateDiGraph(txs, rwAccessedBy)
 	return graph.GetTopo()
 }
+
+// DumpGraph 导出某个区块的冲突图，format为dot、graphml或json，顶点附带交易哈希和gas；
+// reduce时先对有向图做传递约简，只保留直接依赖
+func DumpGraph(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64, format string, directed, reduce bool, w io.Writer) error {
+	txs, _, rwAccessedBy := GetTxsAndPredicts(blockReader, ctx, dbTx, blockNum)
+	label := func(id uint) string {
+		return txs[id].Hash().Hex()
+	}
+
+	type exporter interface {
+		WriteDOT(io.Writer, conflictgraph.VertexLabel) error
+		WriteGraphML(io.Writer, conflictgraph.VertexLabel) error
+		WriteJSON(io.Writer, conflictgraph.VertexLabel) error
+	}
+	var graph exporter
+	if directed {
+		diGraph := GenerateDiGraph(txs, rwAccessedBy)
+		if reduce {
+			diGraph.TransitiveReduction()
+		}
+		graph = diGraph
+	} else {
+		graph = GenerateUndiGraph(txs, rwAccessedBy)
+	}
+	switch format {
+	case "dot":
+		return graph.WriteDOT(w, label)
+	case "graphml":
+		return graph.WriteGraphML(w, label)
+	case "json":
+		return graph.WriteJSON(w, label)
+	default:
+		return fmt.Errorf("unknown graph format %q", format)
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"erigonInteract/accesslist"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func checkUnionFindGroups(t *testing.T, rwSets accesslist.RWSetList) {
+	txs, rwAccessedBy := newTestBlock(rwSets)
+	got := canonicalGroups(GenerateUnionFindGroups(txs, rwAccessedBy))
+	want := canonicalGroups(GenerateUndiGraph(txs, rwAccessedBy).GetConnectedComponents())
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("union-find groups %v, want %v", got, want)
+	}
+}
+
+func TestUnionFindGroups(t *testing.T) {
+	a, b, c, d := testKey(1, 1), testKey(2, 0), testKey(3, 1), testKey(4, 1)
+	checkUnionFindGroups(t, accesslist.RWSetList{
+		// a只有读者，c只有加法写者，都不连边
+		newTestRWSet(keys{a}, nil, keys{c}),
+		newTestRWSet(keys{a}, nil, keys{c}),
+		// 同一笔交易对b既读又加法写，不和自己连
+		newTestRWSet(keys{b}, nil, keys{b}),
+		// d: 加法写者和读者连通
+		newTestRWSet(nil, nil, keys{d}),
+		newTestRWSet(nil, nil, keys{d}),
+		newTestRWSet(keys{d}, nil, nil),
+		nil,
+	})
+	// b的读者和加法写者是不同的交易时连通
+	checkUnionFindGroups(t, accesslist.RWSetList{
+		newTestRWSet(keys{b}, nil, keys{b}),
+		newTestRWSet(keys{b}, nil, nil),
+		newTestRWSet(keys{a}, nil, nil),
+	})
+
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 300; iter++ {
+		checkUnionFindGroups(t, randomRWSets(r, 1+r.Intn(40), 1+r.Intn(10)))
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"erigonInteract/accesslist"
+	conflictgraph "erigonInteract/conflictGraph"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// sparseKeyEdges 一个key上的稀疏冲突边: 写者按tid串成链，读者和加法写者只连前后最近的写者，
+// 同一段(两个写者之间)内的加法写和读仍然两两相连；每条边都是稠密图中的边，
+// 稠密图中的每条边在稀疏图中都有对应的路径，所以可达性和GetTopo的分层不变
+func sparseKeyEdges(wTxs, rTxs, dTxs []uint, addEdge func(from, to uint, t conflictgraph.EdgeType)) {
+	for i := 0; i+1 < len(wTxs); i++ {
+		addEdge(wTxs[i], wTxs[i+1], conflictgraph.EdgeType(accesslist.WAW))
+	}
+
+	// 按所在的段分组，段号为后一个写者在wTxs中的下标
+	segReaders := make(map[int][]uint)
+	segDeltas := make(map[int][]uint)
+	link := func(txId uint, isReader bool) (int, bool) {
+		seg := sort.Search(len(wTxs), func(i int) bool {
+			return wTxs[i] >= txId
+		})
+		// 自己也是写者时已经在链上了
+		if seg < len(wTxs) && wTxs[seg] == txId {
+			return 0, false
+		}
+		t := conflictgraph.EdgeType(accesslist.WAW)
+		if seg > 0 {
+			if isReader {
+				t = readWriteType(wTxs[seg-1], txId)
+			}
+			addEdge(wTxs[seg-1], txId, t)
+		}
+		if seg < len(wTxs) {
+			if isReader {
+				t = readWriteType(wTxs[seg], txId)
+			}
+			addEdge(txId, wTxs[seg], t)
+		}
+		return seg, true
+	}
+	for _, rTx := range rTxs {
+		if seg, ok := link(rTx, true); ok {
+			segReaders[seg] = append(segReaders[seg], rTx)
+		}
+	}
+	for _, dTx := range dTxs {
+		if seg, ok := link(dTx, false); ok {
+			segDeltas[seg] = append(segDeltas[seg], dTx)
+		}
+	}
+
+	// 段内没有写者可以中转，加法写与读之间保留所有边
+	for seg, deltas := range segDeltas {
+		for _, dTx := range deltas {
+			for _, rTx := range segReaders[seg] {
+				if rTx == dTx {
+					continue
+				}
+				addEdge(min(dTx, rTx), max(dTx, rTx), readWriteType(dTx, rTx))
+			}
+		}
+	}
+}
+
+// GenerateSparseUndiGraph 与GenerateUndiGraph的连通分量相同，但热点key上只有O(k)条边
+func GenerateSparseUndiGraph(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) *conflictgraph.UndirectedGraph {
+	undiConfGraph := conflictgraph.NewUndirectedGraph()
+	for i, tx := range txs {
+		undiConfGraph.AddVertexWithCost(uint(i), tx.GetGas())
+	}
+	for keyId := 0; keyId < rwAccessedBy.Keys.Len(); keyId++ {
+		sparseKeyEdges(rwAccessedBy.WriteBy.TxIds(uint32(keyId)), rwAccessedBy.ReadBy.TxIds(uint32(keyId)), rwAccessedBy.DeltaBy.TxIds(uint32(keyId)), undiConfGraph.AddTypedEdge)
+	}
+	return undiConfGraph
+}
+
+// GenerateSparseDiGraph 与GenerateDiGraph的可达关系和GetTopo分层相同，但热点key上只有O(k)条边
+func GenerateSparseDiGraph(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) *conflictgraph.DirectedGraph {
+	Graph := conflictgraph.NewDirectedGraph()
+	for i, tx := range txs {
+		Graph.AddVertexWithCost(uint(i), tx.GetGas())
+	}
+	for keyId := 0; keyId < rwAccessedBy.Keys.Len(); keyId++ {
+		sparseKeyEdges(rwAccessedBy.WriteBy.TxIds(uint32(keyId)), rwAccessedBy.ReadBy.TxIds(uint32(keyId)), rwAccessedBy.DeltaBy.TxIds(uint32(keyId)), Graph.AddTypedEdge)
+	}
+	return Graph
+}
+
+// 分组内和分组之间都排好序，便于比较
+func canonicalGroups(groups [][]uint) [][]uint {
+	res := make([][]uint, 0, len(groups))
+	for _, group := range groups {
+		group = append([]uint(nil), group...)
+		sort.Slice(group, func(i, j int) bool {
+			return group[i] < group[j]
+		})
+		res = append(res, group)
+	}
+	return res
+}
+
+// VerifySparseGraph 校验稀疏图与稠密图的连通分量和GetTopo分层是否一致
+func VerifySparseGraph(txs types.Transactions, rwAccessedBy *accesslist.RwAccessedBy) error {
+	denseComponents := canonicalGroups(GenerateUndiGraph(txs, rwAccessedBy).GetConnectedComponents())
+	sparseComponents := canonicalGroups(GenerateSparseUndiGraph(txs, rwAccessedBy).GetConnectedComponents())
+	sortByFirst := func(groups [][]uint) {
+		sort.Slice(groups, func(i, j int) bool {
+			return groups[i][0] < groups[j][0]
+		})
+	}
+	sortByFirst(denseComponents)
+	sortByFirst(sparseComponents)
+	if !reflect.DeepEqual(denseComponents, sparseComponents) {
+		return fmt.Errorf("components mismatch: dense %v, sparse %v", denseComponents, sparseComponents)
+	}
+
+	// 层的顺序就是执行顺序，不能重排
+	denseLevels, err := GenerateDiGraph(txs, rwAccessedBy).GetTopo()
+	if err != nil {
+		return err
+	}
+	sparseLevels, err := GenerateSparseDiGraph(txs, rwAccessedBy).GetTopo()
+	if err != nil {
+		return err
+	}
+	denseLevels, sparseLevels = canonicalGroups(denseLevels), canonicalGroups(sparseLevels)
+	if !reflect.DeepEqual(denseLevels, sparseLevels) {
+		return fmt.Errorf("topo levels mismatch: dense %v, sparse %v", denseLevels, sparseLevels)
+	}
+	return nil
+}
+
+// 稀疏建图测试，对比边数、建图时间，并校验结果与稠密图一致
+func SparseGraphTest(blockReader *freezeblocks.BlockReader, ctx context.Context, dbTx kv.Tx, blockNum uint64) error {
+	sparsefile, err := os.Create(("sparse.csv"))
+	if err != nil {
+		panic(err)
+	}
+	defer sparsefile.Close()
+	sparseWriter := csv.NewWriter(sparsefile)
+	defer sparseWriter.Flush()
+
+	err = sparseWriter.Write([]string{"BlockNum", "TxNum", "denseEdges", "sparseEdges", "denseGraph", "sparseGraph", "match"})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("test start")
+	for i := 0; i < 500; i++ {
+		blockNum := blockNum + uint64(i)
+		fmt.Println("blockNum:", blockNum)
+		txs, _, rwAccessedBy := GetTxsAndPredicts(blockReader, ctx, dbTx, blockNum)
+
+		denseStart := time.Now()
+		dense := GenerateDiGraph(txs, rwAccessedBy)
+		denseTime := time.Since(denseStart)
+		sparseStart := time.Now()
+		sparse := GenerateSparseDiGraph(txs, rwAccessedBy)
+		sparseTime := time.Since(sparseStart)
+
+		match := true
+		if err := VerifySparseGraph(txs, rwAccessedBy); err != nil {
+			fmt.Println("blockNum:", blockNum, err)
+			match = false
+		}
+		err = sparseWriter.Write([]string{fmt.Sprint(blockNum), fmt.Sprint(len(txs)), fmt.Sprint(dense.EdgeNum()), fmt.Sprint(sparse.EdgeNum()), fmt.Sprint(denseTime.Microseconds()), fmt.Sprint(sparseTime.Microseconds()), fmt.Sprint(match)})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"erigonInteract/accesslist"
+	conflictgraph "erigonInteract/conflictGraph"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+type testEdge struct {
+	from, to uint
+}
+
+func TestSparseKeyEdges(t *testing.T) {
+	raw := conflictgraph.EdgeType(accesslist.RAW)
+	war := conflictgraph.EdgeType(accesslist.WAR)
+	waw := conflictgraph.EdgeType(accesslist.WAW)
+	cases := []struct {
+		name      string
+		w, r, d   []uint
+		wantEdges map[testEdge]conflictgraph.EdgeType
+	}{
+		{
+			// 读者和加法写者都在写者5之前，段内两两相连
+			name: "readers and deltas in one segment",
+			w:    []uint{5}, r: []uint{1, 3}, d: []uint{2},
+			wantEdges: map[testEdge]conflictgraph.EdgeType{
+				{1, 5}: war, {3, 5}: war, {2, 5}: waw, {1, 2}: war, {2, 3}: raw,
+			},
+		},
+		{
+			// 2既读又写，只在写者链上；读者1只连最近的写者2，不连4
+			name: "writer also reads",
+			w:    []uint{2, 4}, r: []uint{1, 2, 3, 5},
+			wantEdges: map[testEdge]conflictgraph.EdgeType{
+				{2, 4}: waw, {1, 2}: war, {2, 3}: raw, {3, 4}: war, {4, 5}: raw,
+			},
+		},
+		{
+			// 不同段的加法写和读之间靠写者中转，不直接相连
+			name: "deltas and readers in different segments",
+			w:    []uint{3}, r: []uint{1}, d: []uint{5},
+			wantEdges: map[testEdge]conflictgraph.EdgeType{
+				{1, 3}: war, {3, 5}: waw,
+			},
+		},
+		{
+			name: "deltas only",
+			d:    []uint{1, 2, 3},
+		},
+		{
+			name: "deltas and a reader",
+			r:    []uint{4}, d: []uint{1, 2},
+			wantEdges: map[testEdge]conflictgraph.EdgeType{
+				{1, 4}: raw, {2, 4}: raw,
+			},
+		},
+		{
+			// 同一笔交易读自己加法写的key，没有边
+			name: "reader is its own delta",
+			r:    []uint{3}, d: []uint{3},
+		},
+	}
+	for _, c := range cases {
+		edges := make(map[testEdge]conflictgraph.EdgeType)
+		sparseKeyEdges(c.w, c.r, c.d, func(from, to uint, edgeType conflictgraph.EdgeType) {
+			if from >= to {
+				t.Fatalf("%s: edge %d -> %d goes backwards", c.name, from, to)
+			}
+			edges[testEdge{from, to}] |= edgeType
+		})
+		if c.wantEdges == nil {
+			c.wantEdges = map[testEdge]conflictgraph.EdgeType{}
+		}
+		if !reflect.DeepEqual(edges, c.wantEdges) {
+			t.Errorf("%s: got edges %v, want %v", c.name, edges, c.wantEdges)
+		}
+	}
+}
+
+func TestVerifySparseGraph(t *testing.T) {
+	a, b, c := testKey(1, 1), testKey(2, 0), testKey(3, 1)
+	_, rwAccessedBy := newTestBlock(nil)
+	if err := VerifySparseGraph(nil, rwAccessedBy); err != nil {
+		t.Fatal(err)
+	}
+	txs, rwAccessedBy := newTestBlock(accesslist.RWSetList{
+		newTestRWSet(keys{a, b}, nil, nil),
+		newTestRWSet(nil, nil, keys{b, c}),
+		newTestRWSet(keys{a}, keys{a}, keys{c}),
+		newTestRWSet(keys{b}, nil, keys{b}),
+		newTestRWSet(keys{a}, nil, nil),
+		newTestRWSet(nil, keys{a}, nil),
+	})
+	if err := VerifySparseGraph(txs, rwAccessedBy); err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 300; iter++ {
+		txs, rwAccessedBy := newTestBlock(randomRWSets(r, 1+r.Intn(40), 1+r.Intn(10)))
+		if err := VerifySparseGraph(txs, rwAccessedBy); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
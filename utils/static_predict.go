@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"bytes"
+	"erigonInteract/accesslist"
+	"fmt"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// PredictMode 选择GetTxsAndPredicts使用的预测器
+type PredictMode int
+
+const (
+	PredictEVM    PredictMode = iota // 全部执行EVM预测
+	PredictStatic                    // 能静态预测的交易不执行EVM
+	PredictVerify                    // 两种都跑，不一致时打印差异并采用EVM的结果
+)
+
+// Predictor GetTxsAndPredicts使用的预测器，默认执行EVM，静态预测和校验需要显式选择
+var Predictor = PredictEVM
+
+// CacheKind 不同预测器的结果分开缓存；校验模式的输出和EVM预测一致，共用一份缓存
+func (mode PredictMode) CacheKind() string {
+	if mode == PredictStatic {
+		return StaticPredictCacheKind
+	}
+	return PredictCacheKind
+}
+
+// StaticState 静态预测需要查询的状态，*state.IntraBlockState实现了这个接口
+type StaticState interface {
+	GetCodeSize(common.Address) int
+	GetState(common.Address, *common.Hash, *uint256.Int)
+}
+
+// ERC20Layout solidity中balances和allowances两个mapping所在的slot
+type ERC20Layout struct {
+	BalanceSlot   uint64
+	AllowanceSlot uint64
+}
+
+// ERC20Layouts 已知存储布局的代币，只有这些代币的调用会走静态预测；
+// 代币的转账逻辑必须和WETH/DAI一样只访问这两个mapping
+var ERC20Layouts = map[common.Address]ERC20Layout{
+	common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"): {BalanceSlot: 3, AllowanceSlot: 4}, // WETH
+	common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"): {BalanceSlot: 2, AllowanceSlot: 3}, // DAI
+}
+
+var (
+	transferSelector     = []byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+	transferFromSelector = []byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+	approveSelector      = []byte{0x09, 0x5e, 0xa7, 0xb3} // approve(address,uint256)
+)
+
+// MappingSlot mapping(key => ...)在slot处的元素位置: keccak256(pad32(key) ++ pad32(slot))
+func MappingSlot(key common.Hash, slot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(key[:], slot[:])
+}
+
+func (layout ERC20Layout) balanceKey(owner common.Address) common.Hash {
+	return MappingSlot(common.BytesToHash(owner[:]), common.Hash(new(uint256.Int).SetUint64(layout.BalanceSlot).Bytes32()))
+}
+
+// allowance[owner][spender]
+func (layout ERC20Layout) allowanceKey(owner, spender common.Address) common.Hash {
+	inner := MappingSlot(common.BytesToHash(owner[:]), common.Hash(new(uint256.Int).SetUint64(layout.AllowanceSlot).Bytes32()))
+	return MappingSlot(common.BytesToHash(spender[:]), inner)
+}
+
+// 取calldata中第i个参数作为地址，高12字节不为0说明不是合法的地址
+func addressArg(args []byte, i int) (common.Address, bool) {
+	word := args[32*i : 32*(i+1)]
+	if !bytes.Equal(word[:12], make([]byte, 12)) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(word[12:]), true
+}
+
+// 所有交易都会访问的部分：发送者的nonce和余额，接收者的代码和余额，以及矿工的余额
+func envelopeRWSet(sender, to, coinbase common.Address) *accesslist.RWSet {
+	rwSet := accesslist.NewRWSet()
+	rwSet.AddReadSet(sender, accesslist.NONCE)
+	rwSet.AddReadSet(sender, accesslist.BALANCE)
+	rwSet.AddReadSet(sender, accesslist.CODEHASH)
+	rwSet.AddWriteSet(sender, accesslist.NONCE)
+	rwSet.AddWriteSet(sender, accesslist.BALANCE)
+	rwSet.AddReadSet(to, accesslist.CODE)
+	rwSet.AddDeltaSet(to, accesslist.BALANCE)
+	rwSet.AddDeltaSet(coinbase, accesslist.BALANCE)
+	return rwSet
+}
+
+// StaticPredictRWSets 不执行EVM，直接从交易推导读写集，支持普通转账和已知代币的transfer/transferFrom/approve；
+// ibs只用来查询接收者是否有代码和当前的allowance，ok为false时需要回退到PredictRWSets
+func StaticPredictRWSets(ibs StaticState, coinbase common.Address, tx types.Transaction) (*accesslist.RWSet, bool) {
+	to := tx.GetTo()
+	if to == nil {
+		return nil, false
+	}
+	sender, err := tx.Sender(*types.LatestSigner(params.MainnetChainConfig))
+	if err != nil {
+		return nil, false
+	}
+	data := tx.GetData()
+
+	// 普通转账，接收者不能是合约，否则会执行receive/fallback
+	if len(data) == 0 {
+		if ibs.GetCodeSize(*to) != 0 {
+			return nil, false
+		}
+		return envelopeRWSet(sender, *to, coinbase), true
+	}
+
+	layout, ok := ERC20Layouts[*to]
+	if !ok || len(data) < 4 || !tx.GetValue().IsZero() {
+		return nil, false
+	}
+	rwSet := envelopeRWSet(sender, *to, coinbase)
+	rwSet.AddReadSet(*to, accesslist.CODEHASH)
+	selector, args := data[:4], data[4:]
+	switch {
+	case bytes.Equal(selector, transferSelector) && len(args) >= 64:
+		dst, ok := addressArg(args, 0)
+		if !ok {
+			return nil, false
+		}
+		for _, owner := range []common.Address{sender, dst} {
+			rwSet.AddReadSet(*to, layout.balanceKey(owner))
+			rwSet.AddWriteSet(*to, layout.balanceKey(owner))
+		}
+	case bytes.Equal(selector, transferFromSelector) && len(args) >= 96:
+		src, ok1 := addressArg(args, 0)
+		dst, ok2 := addressArg(args, 1)
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		for _, owner := range []common.Address{src, dst} {
+			rwSet.AddReadSet(*to, layout.balanceKey(owner))
+			rwSet.AddWriteSet(*to, layout.balanceKey(owner))
+		}
+		// 代替别人转账时要检查allowance，无限授权不会被扣减
+		if src != sender {
+			key := layout.allowanceKey(src, sender)
+			rwSet.AddReadSet(*to, key)
+			var allowance uint256.Int
+			ibs.GetState(*to, &key, &allowance)
+			if !allowance.Eq(new(uint256.Int).SetAllOne()) {
+				rwSet.AddWriteSet(*to, key)
+			}
+		}
+	case bytes.Equal(selector, approveSelector) && len(args) >= 64:
+		spender, ok := addressArg(args, 0)
+		if !ok {
+			return nil, false
+		}
+		// SSTORE计gas时会先读旧值，EVM预测也会记下这次读
+		key := layout.allowanceKey(sender, spender)
+		rwSet.AddReadSet(*to, key)
+		rwSet.AddWriteSet(*to, key)
+	default:
+		return nil, false
+	}
+	return rwSet, true
+}
+
+// PredictRWSetsFast 优先静态预测，无法静态预测的交易再执行EVM
+func PredictRWSetsFast(ibs StaticState, blkCtx evmtypes.BlockContext, header *types.Header, dbTx kv.Tx, tx types.Transaction, blockNum uint64) *accesslist.RWSet {
+	if rwSet, ok := StaticPredictRWSets(ibs, blkCtx.Coinbase, tx); ok {
+		return rwSet
+	}
+	return PredictRWSets(blkCtx, header, dbTx, tx, blockNum)
+}
+
+// PredictRWSetsVerify 静态预测和EVM预测都跑一遍，不一致时打印两者并返回EVM的结果
+func PredictRWSetsVerify(ibs StaticState, blkCtx evmtypes.BlockContext, header *types.Header, dbTx kv.Tx, tx types.Transaction, blockNum uint64) *accesslist.RWSet {
+	evmSet := PredictRWSets(blkCtx, header, dbTx, tx, blockNum)
+	staticSet, ok := StaticPredictRWSets(ibs, blkCtx.Coinbase, tx)
+	if !ok || evmSet == nil || staticSet.Equal(*evmSet) {
+		return evmSet
+	}
+	fmt.Println("Static predict mismatch, tx hash:", tx.Hash())
+	fmt.Println("static:", staticSet.ToJsonStruct().ToString())
+	fmt.Println("evm:", evmSet.ToJsonStruct().ToString())
+	return evmSet
+}
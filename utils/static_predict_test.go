@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"erigonInteract/accesslist"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+var (
+	weth     = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	dai      = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	owner    = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	spender  = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	coinbase = common.HexToAddress("0x3333333333333333333333333333333333333333")
+)
+
+// 静态预测只用到发送者、接收者、calldata和value
+type staticTx struct {
+	types.Transaction
+	from  common.Address
+	to    *common.Address
+	data  []byte
+	value *uint256.Int
+}
+
+func (tx staticTx) Sender(types.Signer) (common.Address, error) { return tx.from, nil }
+func (tx staticTx) GetTo() *common.Address                      { return tx.to }
+func (tx staticTx) GetData() []byte                             { return tx.data }
+func (tx staticTx) GetValue() *uint256.Int                      { return tx.value }
+
+type staticState struct {
+	code    map[common.Address]int
+	storage map[common.Address]map[common.Hash]uint256.Int
+}
+
+func (s staticState) GetCodeSize(addr common.Address) int { return s.code[addr] }
+
+func (s staticState) GetState(addr common.Address, key *common.Hash, value *uint256.Int) {
+	*value = s.storage[addr][*key]
+}
+
+func addressWord(addr common.Address) []byte {
+	return common.BytesToHash(addr[:]).Bytes()
+}
+
+func callData(selector []byte, words ...[]byte) []byte {
+	data := append([]byte{}, selector...)
+	for _, word := range words {
+		data = append(data, word...)
+	}
+	return data
+}
+
+func TestERC20Slots(t *testing.T) {
+	if ERC20Layouts[weth] != (ERC20Layout{BalanceSlot: 3, AllowanceSlot: 4}) {
+		t.Fatalf("unexpected WETH layout %+v", ERC20Layouts[weth])
+	}
+	if ERC20Layouts[dai] != (ERC20Layout{BalanceSlot: 2, AllowanceSlot: 3}) {
+		t.Fatalf("unexpected DAI layout %+v", ERC20Layouts[dai])
+	}
+
+	// keccak256(pad32(key) ++ pad32(slot))，allowance是两层mapping
+	cases := []struct {
+		name string
+		got  common.Hash
+		want string
+	}{
+		{"WETH balance", ERC20Layouts[weth].balanceKey(owner), "0xfc40ea33816453f766ebc0872d4b5152b468882abe7b6b35528069db4d6e41c4"},
+		{"WETH allowance", ERC20Layouts[weth].allowanceKey(owner, spender), "0x84648e0fe4d920526e7b69790b876df2ac5731cd950455df59e4be38025f60ed"},
+		{"DAI balance", ERC20Layouts[dai].balanceKey(owner), "0x06bb1b9bc4293ba066a12274418b7ea4df183c2e4e6b39591987369520ca3956"},
+		{"DAI allowance", ERC20Layouts[dai].allowanceKey(owner, spender), "0xc96456ce834b6529dce91df9351452f956d10efb9c25c388a6ebf96f71229604"},
+		{"mapping slot", MappingSlot(common.BytesToHash(owner[:]), common.BytesToHash([]byte{3})), "0xfc40ea33816453f766ebc0872d4b5152b468882abe7b6b35528069db4d6e41c4"},
+	}
+	for _, c := range cases {
+		if c.got != common.HexToHash(c.want) {
+			t.Errorf("%s: got %s, want %s", c.name, c.got.Hex(), c.want)
+		}
+	}
+}
+
+func TestStaticPredictRWSets(t *testing.T) {
+	contract := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	infinite := new(uint256.Int).SetAllOne()
+	ibs := staticState{
+		code: map[common.Address]int{contract: 100, weth: 100, dai: 100},
+		storage: map[common.Address]map[common.Hash]uint256.Int{
+			weth: {ERC20Layouts[weth].allowanceKey(owner, spender): *infinite},
+			dai:  {ERC20Layouts[dai].allowanceKey(owner, spender): *uint256.NewInt(10)},
+		},
+	}
+	zero := new(uint256.Int)
+	amount := common.BytesToHash([]byte{1}).Bytes()
+
+	// 代币调用在信封之外还会读代币合约的CODEHASH
+	tokenEnvelope := func(sender, token common.Address) *accesslist.RWSet {
+		rwSet := envelopeRWSet(sender, token, coinbase)
+		rwSet.AddReadSet(token, accesslist.CODEHASH)
+		return rwSet
+	}
+	balances := func(rwSet *accesslist.RWSet, token common.Address, owners ...common.Address) *accesslist.RWSet {
+		for _, o := range owners {
+			rwSet.AddReadSet(token, ERC20Layouts[token].balanceKey(o))
+			rwSet.AddWriteSet(token, ERC20Layouts[token].balanceKey(o))
+		}
+		return rwSet
+	}
+
+	transferTo := spender
+	approve := tokenEnvelope(owner, dai)
+	approve.AddReadSet(dai, ERC20Layouts[dai].allowanceKey(owner, spender))
+	approve.AddWriteSet(dai, ERC20Layouts[dai].allowanceKey(owner, spender))
+	finite := balances(tokenEnvelope(spender, dai), dai, owner, coinbase)
+	finite.AddReadSet(dai, ERC20Layouts[dai].allowanceKey(owner, spender))
+	finite.AddWriteSet(dai, ERC20Layouts[dai].allowanceKey(owner, spender))
+	unlimited := balances(tokenEnvelope(spender, weth), weth, owner, coinbase)
+	unlimited.AddReadSet(weth, ERC20Layouts[weth].allowanceKey(owner, spender))
+
+	cases := []struct {
+		name string
+		tx   staticTx
+		want *accesslist.RWSet // nil表示需要回退到EVM
+	}{
+		{"plain transfer", staticTx{from: owner, to: &transferTo, value: uint256.NewInt(1)}, envelopeRWSet(owner, spender, coinbase)},
+		{"transfer to contract", staticTx{from: owner, to: &contract, value: zero}, nil},
+		{"contract creation", staticTx{from: owner, value: zero}, nil},
+		{"WETH transfer", staticTx{from: owner, to: &weth, value: zero,
+			data: callData(transferSelector, addressWord(spender), amount)}, balances(tokenEnvelope(owner, weth), weth, owner, spender)},
+		{"DAI transferFrom with allowance", staticTx{from: spender, to: &dai, value: zero,
+			data: callData(transferFromSelector, addressWord(owner), addressWord(coinbase), amount)}, finite},
+		{"WETH transferFrom with infinite allowance", staticTx{from: spender, to: &weth, value: zero,
+			data: callData(transferFromSelector, addressWord(owner), addressWord(coinbase), amount)}, unlimited},
+		{"transferFrom by owner", staticTx{from: owner, to: &weth, value: zero,
+			data: callData(transferFromSelector, addressWord(owner), addressWord(spender), amount)}, balances(tokenEnvelope(owner, weth), weth, owner, spender)},
+		{"DAI approve", staticTx{from: owner, to: &dai, value: zero,
+			data: callData(approveSelector, addressWord(spender), amount)}, approve},
+		{"unknown token", staticTx{from: owner, to: &contract, value: zero,
+			data: callData(transferSelector, addressWord(spender), amount)}, nil},
+		{"token call with value", staticTx{from: owner, to: &weth, value: uint256.NewInt(1),
+			data: callData(transferSelector, addressWord(spender), amount)}, nil},
+		{"unknown selector", staticTx{from: owner, to: &weth, value: zero,
+			data: callData([]byte{0xd0, 0xe3, 0x0d, 0xb0})}, nil},
+		{"short calldata", staticTx{from: owner, to: &weth, value: zero,
+			data: callData(transferSelector, addressWord(spender))}, nil},
+		{"dirty address", staticTx{from: owner, to: &weth, value: zero,
+			data: callData(transferSelector, common.HexToHash("0x01"+spender.Hex()[2:]).Bytes(), amount)}, nil},
+	}
+	for _, c := range cases {
+		got, ok := StaticPredictRWSets(ibs, coinbase, c.tx)
+		if c.want == nil {
+			if ok {
+				t.Errorf("%s: expected fallback to EVM", c.name)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s: unexpected fallback to EVM", c.name)
+			continue
+		}
+		if !got.Equal(*c.want) {
+			t.Errorf("%s: got %s, want %s", c.name, got.ToJsonStruct().ToString(), c.want.ToJsonStruct().ToString())
+		}
+	}
+}
+
+func TestEnvelopeRWSet(t *testing.T) {
+	rwSet := envelopeRWSet(owner, spender, coinbase)
+	reads := []accesslist.AccessKey{{Addr: owner, Hash: accesslist.NONCE}, {Addr: owner, Hash: accesslist.BALANCE},
+		{Addr: owner, Hash: accesslist.CODEHASH}, {Addr: spender, Hash: accesslist.CODE}}
+	writes := []accesslist.AccessKey{{Addr: owner, Hash: accesslist.NONCE}, {Addr: owner, Hash: accesslist.BALANCE}}
+	deltas := []accesslist.AccessKey{{Addr: spender, Hash: accesslist.BALANCE}, {Addr: coinbase, Hash: accesslist.BALANCE}}
+	if !rwSet.Equal(*newTestRWSet(reads, writes, deltas)) {
+		t.Fatalf("unexpected envelope %s", rwSet.ToJsonStruct().ToString())
+	}
+	if PredictStatic.CacheKind() == PredictEVM.CacheKind() || PredictVerify.CacheKind() != PredictEVM.CacheKind() {
+		t.Fatal("static predictions must not share the EVM cache")
+	}
+}